@@ -1,17 +1,353 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"evm-chain-relayer/v2"
+	"flag"
+	"fmt"
+	"github.com/ethereum/go-ethereum/log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
+// version and gitCommit can be overwritten at build time via -ldflags "-X main.gitCommit=..."
+var (
+	version   = "0.1.0"
+	gitCommit = ""
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []*command{
+	{name: "start", usage: "run the relayer until SIGINT/SIGTERM, reload the config on SIGHUP", run: startCmd},
+	{name: "check-config", usage: "load and validate the config file without connecting to any chain", run: checkConfigCmd},
+	{name: "check", usage: "validate the config file against the live chains", run: checkCmd},
+	{name: "export", usage: "back up the jobs, checkpoints, header cache and nonces to a JSON-lines file", run: exportCmd},
+	{name: "import", usage: "restore a file written by export into an empty storage", run: importCmd},
+	{name: "inspect", usage: "print the job counts per stage, the disputed jobs, the oldest pending job per route and the checkpoints", run: inspectCmd},
+	{name: "version", usage: "print the version", run: versionCmd},
+}
+
+type commonFlags struct {
+	config    string
+	dataDir   string
+	verbosity int
+}
+
+func newFlagSet(name string) (*flag.FlagSet, *commonFlags) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	cf := new(commonFlags)
+	fs.StringVar(&cf.config, "config", "./config.json", "path of the relayer config file")
+	fs.StringVar(&cf.dataDir, "datadir", ".", "directory that a relative storage dir is resolved against")
+	fs.IntVar(&cf.verbosity, "verbosity", 3, "log level: 0=silent, 1=error, 2=warn, 3=info, 4=debug, 5=trace")
+	return fs, cf
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-14s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun '%s <command> -h' for the flags of a command\n", os.Args[0])
+}
+
 func main() {
-	// 1. Monitor Subscription
-	go v2.GlobalCoordinator.Start()
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == os.Args[1] {
+			if err := cmd.run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", cmd.name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", os.Args[1])
+	usage()
+	os.Exit(2)
+}
+
+func startCmd(args []string) error {
+	fs, cf := newFlagSet("start")
+	adminAddr := fs.String("admin", "", "listen address of the admin http endpoint, POST /reload reloads the config file and GET /usage "+
+		"returns the request counters of the rpc endpoints (disabled if empty)")
+	fs.Parse(args)
+
+	cfg, err := v2.LoadConfig(cf.config)
+	if err != nil {
+		return err
+	}
+
+	v2.SetupLogger(cf.verbosity)
+	coordinator, err := v2.NewCoordinatorFromConfig(cfg, cf.dataDir)
+	if err != nil {
+		return err
+	}
+
+	reload := func() error {
+		cfg, err := v2.LoadConfig(cf.config)
+		if err != nil {
+			return err
+		}
+		return coordinator.Reload(cfg)
+	}
+
+	if *adminAddr != "" {
+		server := newAdminServer(*adminAddr, reload, coordinator.RpcUsage)
+		go func() {
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Error("main::startCmd() admin server stopped", "addr", *adminAddr, "err", err.Error())
+			}
+		}()
+		defer server.Close()
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- coordinator.Start()
+	}()
+
+	for {
+		select {
+		case sig := <-sigCh:
+			if sig == syscall.SIGHUP {
+				log.Info("main::startCmd() receive SIGHUP, reloading the config", "config", cf.config)
+				if err = reload(); err != nil {
+					log.Error("main::startCmd() failed to reload the config", "config", cf.config, "err", err.Error())
+				}
+				continue
+			}
+			log.Info("main::startCmd() receive signal, stopping the coordinator", "signal", sig)
+			coordinator.Stop()
+			return <-errCh
+		case err = <-errCh:
+			return err
+		}
+	}
+}
+
+// newAdminServer serves POST /reload, which reloads the config file like SIGHUP does, and GET /usage
+func newAdminServer(addr string, reload func() error, usage func() map[uint64][]*v2.EndpointUsage) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/reload", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := reload(); err != nil {
+			log.Error("main::adminServer() failed to reload the config", "err", err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintln(w, "reloaded")
+	})
+	mux.HandleFunc("/usage", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(usage()); err != nil {
+			log.Error("main::adminServer() failed to encode usage", "err", err.Error())
+		}
+	})
+	return &http.Server{Addr: addr, Handler: mux}
+}
+
+func checkConfigCmd(args []string) error {
+	fs, cf := newFlagSet("check-config")
+	fs.Parse(args)
+
+	cfg, err := v2.LoadConfig(cf.config)
+	if err != nil {
+		return err
+	}
 
-	time.Sleep(1000 * time.Second)
+	contracts, err := v2.NewContractsConfig(cfg.Contracts)
+	if err != nil {
+		return err
+	}
+
+	for _, route := range cfg.Routes {
+		if _, err = v2.NewRoute(route, contracts); err != nil {
+			return err
+		}
+	}
+
+	if _, err = cfg.Key.KeyPassword(); err != nil {
+		return err
+	}
+
+	fmt.Printf("config %s is valid: %d chains, %d contracts, %d routes\n", cf.config, len(cfg.Chains), len(cfg.Contracts), len(cfg.Routes))
+	return nil
+}
+
+func checkCmd(args []string) error {
+	fs, cf := newFlagSet("check")
+	timeout := fs.Duration("timeout", 30*time.Second, "timeout of the whole check")
+	fs.Parse(args)
+
+	cfg, err := v2.LoadConfig(cf.config)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	failed := 0
+	for _, res := range v2.PreflightCheck(ctx, cfg) {
+		if res.Err != nil {
+			failed++
+			fmt.Printf("[FAIL] %s: %v\n", res.Name, res.Err)
+		} else {
+			fmt.Printf("[ OK ] %s\n", res.Name)
+		}
+	}
+
+	if failed != 0 {
+		return fmt.Errorf("%d checks failed", failed)
+	}
+	return nil
+}
+
+// openStorage opens the storage of the config file, it must not be in use by a running relayer
+func openStorage(cf *commonFlags) (v2.Storage, error) {
+	cfg, err := v2.LoadConfig(cf.config)
+	if err != nil {
+		return nil, err
+	}
+	return v2.OpenStorage(cfg.Storage, cf.dataDir)
+}
+
+func exportCmd(args []string) error {
+	fs, cf := newFlagSet("export")
+	out := fs.String("out", "", "path of the exported file (stdout if empty)")
+	fs.Parse(args)
+
+	storage, err := openStorage(cf)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	w := os.Stdout
+	if *out != "" {
+		if w, err = os.Create(*out); err != nil {
+			return err
+		}
+	}
+	count, err := v2.ExportState(storage, w)
+	if err != nil {
+		if *out != "" {
+			w.Close()
+		}
+		return err
+	}
+	if *out != "" {
+		if err = w.Close(); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "exported %d records to %s\n", count, *out)
+	}
+	return nil
+}
+
+func importCmd(args []string) error {
+	fs, cf := newFlagSet("import")
+	in := fs.String("in", "", "path of the file written by export (stdin if empty)")
+	fs.Parse(args)
+
+	storage, err := openStorage(cf)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	r := os.Stdin
+	if *in != "" {
+		if r, err = os.Open(*in); err != nil {
+			return err
+		}
+		defer r.Close()
+	}
+	count, err := v2.ImportState(storage, r)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "imported %d records\n", count)
+	return nil
+}
+
+func inspectCmd(args []string) error {
+	fs, cf := newFlagSet("inspect")
+	fs.Parse(args)
+
+	storage, err := openStorage(cf)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	summary, err := v2.InspectState(storage)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("jobs:")
+	if len(summary.Routes) == 0 {
+		fmt.Println("  none")
+	}
+	for _, rs := range summary.Routes {
+		fmt.Printf("  chain %d route %s: %s %d, %s %d, %s %d, %s %d, %s %d, %s %d, disputed %d\n", rs.ChainId, rs.Route,
+			v2.JobObserved, rs.Stages[v2.JobObserved], v2.JobVerified, rs.Stages[v2.JobVerified], v2.JobScheduled, rs.Stages[v2.JobScheduled],
+			v2.JobSubmitted, rs.Stages[v2.JobSubmitted], v2.JobConfirmed, rs.Stages[v2.JobConfirmed], v2.JobReverted, rs.Stages[v2.JobReverted], rs.Disputed)
+		if job := rs.OldestPending; job != nil {
+			fmt.Printf("    oldest pending: tx %s log %d block %d stage %s created %s", job.TxHash.Hex(), job.LogIndex, job.Log.BlockNumber,
+				job.Stage, time.Unix(job.CreatedAt, 0).UTC().Format(time.RFC3339))
+			if job.LastError != "" {
+				fmt.Printf(" last error: %s", job.LastError)
+			}
+			fmt.Println()
+		}
+	}
+
+	fmt.Println("checkpoints:")
+	if len(summary.Checkpoints) == 0 {
+		fmt.Println("  none")
+	}
+	for _, cp := range summary.Checkpoints {
+		fmt.Printf("  chain %d route %s contract %s event %s: block %d\n", cp.ChainId, cp.Route, cp.Contract.Hex(), cp.EventId.Hex(), cp.Number)
+	}
+
+	for _, head := range summary.Heads {
+		fmt.Printf("header cache of chain %d: head %d\n", head.ChainId, head.Number)
+	}
+	return nil
+}
 
-	v2.GlobalCoordinator.Stop()
-	//fmt.Println("Listening")
-	//v2.GlobalCoordinator.Stop()
+func versionCmd(args []string) error {
+	if gitCommit != "" {
+		fmt.Printf("evm-chain-relayer %s-%s\n", version, gitCommit)
+	} else {
+		fmt.Printf("evm-chain-relayer %s\n", version)
+	}
+	return nil
 }
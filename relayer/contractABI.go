@@ -1,870 +1,13 @@
 package relayer
 
-const (
-	W3qERC20ABI = `[
-{
-"inputs": [
-{
-"internalType": "string",
-"name": "name",
-"type": "string"
-},
-{
-"internalType": "string",
-"name": "symbol",
-"type": "string"
-}
-],
-"stateMutability": "nonpayable",
-"type": "constructor"
-},
-{
-"anonymous": false,
-"inputs": [
-{
-"indexed": true,
-"internalType": "address",
-"name": "owner",
-"type": "address"
-},
-{
-"indexed": true,
-"internalType": "address",
-"name": "spender",
-"type": "address"
-},
-{
-"indexed": false,
-"internalType": "uint256",
-"name": "value",
-"type": "uint256"
-}
-],
-"name": "Approval",
-"type": "event"
-},
-{
-"anonymous": false,
-"inputs": [
-{
-"indexed": true,
-"internalType": "address",
-"name": "previousOwner",
-"type": "address"
-},
-{
-"indexed": true,
-"internalType": "address",
-"name": "newOwner",
-"type": "address"
-}
-],
-"name": "OwnershipTransferred",
-"type": "event"
-},
-{
-"anonymous": false,
-"inputs": [
-{
-"indexed": false,
-"internalType": "address",
-"name": "account",
-"type": "address"
-}
-],
-"name": "Paused",
-"type": "event"
-},
-{
-"anonymous": false,
-"inputs": [
-{
-"indexed": true,
-"internalType": "address",
-"name": "from",
-"type": "address"
-},
-{
-"indexed": true,
-"internalType": "address",
-"name": "to",
-"type": "address"
-},
-{
-"indexed": false,
-"internalType": "uint256",
-"name": "value",
-"type": "uint256"
-}
-],
-"name": "Transfer",
-"type": "event"
-},
-{
-"anonymous": false,
-"inputs": [
-{
-"indexed": false,
-"internalType": "address",
-"name": "account",
-"type": "address"
-}
-],
-"name": "Unpaused",
-"type": "event"
-},
-{
-"anonymous": false,
-"inputs": [
-{
-"indexed": true,
-"internalType": "address",
-"name": "owner",
-"type": "address"
-},
-{
-"indexed": false,
-"internalType": "uint256",
-"name": "amount",
-"type": "uint256"
-}
-],
-"name": "burnToken",
-"type": "event"
-},
-{
-"anonymous": false,
-"inputs": [
-{
-"indexed": false,
-"internalType": "bytes32",
-"name": "txHash",
-"type": "bytes32"
-},
-{
-"indexed": true,
-"internalType": "uint256",
-"name": "logIdx",
-"type": "uint256"
-},
-{
-"indexed": true,
-"internalType": "address",
-"name": "to",
-"type": "address"
-},
-{
-"indexed": false,
-"internalType": "uint256",
-"name": "amount",
-"type": "uint256"
-}
-],
-"name": "mintToken",
-"type": "event"
-},
-{
-"inputs": [],
-"name": "PER_EPOCH_REWARD",
-"outputs": [
-{
-"internalType": "uint256",
-"name": "",
-"type": "uint256"
-}
-],
-"stateMutability": "view",
-"type": "function"
-},
-{
-"inputs": [
-{
-"internalType": "address",
-"name": "owner",
-"type": "address"
-},
-{
-"internalType": "address",
-"name": "spender",
-"type": "address"
-}
-],
-"name": "allowance",
-"outputs": [
-{
-"internalType": "uint256",
-"name": "",
-"type": "uint256"
-}
-],
-"stateMutability": "view",
-"type": "function"
-},
-{
-"inputs": [
-{
-"internalType": "address",
-"name": "spender",
-"type": "address"
-},
-{
-"internalType": "uint256",
-"name": "amount",
-"type": "uint256"
-}
-],
-"name": "approve",
-"outputs": [
-{
-"internalType": "bool",
-"name": "",
-"type": "bool"
-}
-],
-"stateMutability": "nonpayable",
-"type": "function"
-},
-{
-"inputs": [
-{
-"internalType": "address",
-"name": "account",
-"type": "address"
-}
-],
-"name": "balanceOf",
-"outputs": [
-{
-"internalType": "uint256",
-"name": "",
-"type": "uint256"
-}
-],
-"stateMutability": "view",
-"type": "function"
-},
-{
-"inputs": [
-{
-"internalType": "uint256",
-"name": "",
-"type": "uint256"
-}
-],
-"name": "burnNonceUsed",
-"outputs": [
-{
-"internalType": "bool",
-"name": "",
-"type": "bool"
-}
-],
-"stateMutability": "view",
-"type": "function"
-},
-{
-"inputs": [
-{
-"internalType": "address",
-"name": "account",
-"type": "address"
-},
-{
-"internalType": "uint256",
-"name": "amount",
-"type": "uint256"
-}
-],
-"name": "burnToBridge",
-"outputs": [],
-"stateMutability": "nonpayable",
-"type": "function"
-},
-{
-"inputs": [],
-"name": "decimals",
-"outputs": [
-{
-"internalType": "uint8",
-"name": "",
-"type": "uint8"
-}
-],
-"stateMutability": "view",
-"type": "function"
-},
-{
-"inputs": [
-{
-"internalType": "address",
-"name": "spender",
-"type": "address"
-},
-{
-"internalType": "uint256",
-"name": "subtractedValue",
-"type": "uint256"
-}
-],
-"name": "decreaseAllowance",
-"outputs": [
-{
-"internalType": "bool",
-"name": "",
-"type": "bool"
-}
-],
-"stateMutability": "nonpayable",
-"type": "function"
-},
-{
-"inputs": [
-{
-"internalType": "address",
-"name": "spender",
-"type": "address"
-},
-{
-"internalType": "uint256",
-"name": "addedValue",
-"type": "uint256"
-}
-],
-"name": "increaseAllowance",
-"outputs": [
-{
-"internalType": "bool",
-"name": "",
-"type": "bool"
-}
-],
-"stateMutability": "nonpayable",
-"type": "function"
-},
-{
-"inputs": [
-{
-"internalType": "address",
-"name": "account",
-"type": "address"
-},
-{
-"internalType": "uint256",
-"name": "amount",
-"type": "uint256"
-}
-],
-"name": "mint",
-"outputs": [],
-"stateMutability": "nonpayable",
-"type": "function"
-},
-{
-"inputs": [
-{
-"internalType": "uint256",
-"name": "height",
-"type": "uint256"
-},
-{
-"components": [
-{
-"internalType": "bytes",
-"name": "rlpValue",
-"type": "bytes"
-},
-{
-"internalType": "bytes",
-"name": "rlpParentNodes",
-"type": "bytes"
-},
-{
-"internalType": "bytes",
-"name": "encodePath",
-"type": "bytes"
-}
-],
-"internalType": "struct IW3qProver.Proof",
-"name": "proof",
-"type": "tuple"
-},
-{
-"internalType": "uint256",
-"name": "logIdx",
-"type": "uint256"
-}
-],
-"name": "mintToBridge",
-"outputs": [],
-"stateMutability": "nonpayable",
-"type": "function"
-},
-{
-"inputs": [],
-"name": "name",
-"outputs": [
-{
-"internalType": "string",
-"name": "",
-"type": "string"
-}
-],
-"stateMutability": "view",
-"type": "function"
-},
-{
-"inputs": [],
-"name": "owner",
-"outputs": [
-{
-"internalType": "address",
-"name": "",
-"type": "address"
-}
-],
-"stateMutability": "view",
-"type": "function"
-},
-{
-"inputs": [],
-"name": "paused",
-"outputs": [
-{
-"internalType": "bool",
-"name": "",
-"type": "bool"
-}
-],
-"stateMutability": "view",
-"type": "function"
-},
-{
-"inputs": [],
-"name": "perEpochReward",
-"outputs": [
-{
-"internalType": "uint256",
-"name": "",
-"type": "uint256"
-}
-],
-"stateMutability": "pure",
-"type": "function"
-},
-{
-"inputs": [],
-"name": "prover",
-"outputs": [
-{
-"internalType": "contract W3qProver",
-"name": "",
-"type": "address"
-}
-],
-"stateMutability": "view",
-"type": "function"
-},
-{
-"inputs": [],
-"name": "renounceOwnership",
-"outputs": [],
-"stateMutability": "nonpayable",
-"type": "function"
-},
-{
-"inputs": [],
-"name": "symbol",
-"outputs": [
-{
-"internalType": "string",
-"name": "",
-"type": "string"
-}
-],
-"stateMutability": "view",
-"type": "function"
-},
-{
-"inputs": [],
-"name": "tokenOnWeb3q",
-"outputs": [
-{
-"internalType": "address",
-"name": "",
-"type": "address"
-}
-],
-"stateMutability": "view",
-"type": "function"
-},
-{
-"inputs": [],
-"name": "totalSupply",
-"outputs": [
-{
-"internalType": "uint256",
-"name": "",
-"type": "uint256"
-}
-],
-"stateMutability": "view",
-"type": "function"
-},
-{
-"inputs": [
-{
-"internalType": "address",
-"name": "to",
-"type": "address"
-},
-{
-"internalType": "uint256",
-"name": "amount",
-"type": "uint256"
-}
-],
-"name": "transfer",
-"outputs": [
-{
-"internalType": "bool",
-"name": "",
-"type": "bool"
-}
-],
-"stateMutability": "nonpayable",
-"type": "function"
-},
-{
-"inputs": [
-{
-"internalType": "address",
-"name": "from",
-"type": "address"
-},
-{
-"internalType": "address",
-"name": "to",
-"type": "address"
-},
-{
-"internalType": "uint256",
-"name": "amount",
-"type": "uint256"
-}
-],
-"name": "transferFrom",
-"outputs": [
-{
-"internalType": "bool",
-"name": "",
-"type": "bool"
-}
-],
-"stateMutability": "nonpayable",
-"type": "function"
-},
-{
-"inputs": [
-{
-"internalType": "address",
-"name": "newOwner",
-"type": "address"
-}
-],
-"name": "transferOwnership",
-"outputs": [],
-"stateMutability": "nonpayable",
-"type": "function"
-}
-]`
-	W3qNativeTestABI = `[
-		{
-			"anonymous": false,
-			"inputs": [
-				{
-					"indexed": true,
-					"internalType": "uint256",
-					"name": "nonce",
-					"type": "uint256"
-				},
-				{
-					"indexed": true,
-					"internalType": "address",
-					"name": "to",
-					"type": "address"
-				},
-				{
-					"indexed": false,
-					"internalType": "uint256",
-					"name": "amount",
-					"type": "uint256"
-				}
-			],
-			"name": "burnNativeToken",
-			"type": "event"
-		},
-		{
-			"anonymous": false,
-			"inputs": [
-				{
-					"indexed": false,
-					"internalType": "address",
-					"name": "to",
-					"type": "address"
-				},
-				{
-					"indexed": false,
-					"internalType": "uint256",
-					"name": "amount",
-					"type": "uint256"
-				}
-			],
-			"name": "mintNT",
-			"type": "event"
-		},
-		{
-			"anonymous": false,
-			"inputs": [
-				{
-					"indexed": true,
-					"internalType": "bytes32",
-					"name": "txHash",
-					"type": "bytes32"
-				},
-				{
-					"indexed": true,
-					"internalType": "uint256",
-					"name": "logIdx",
-					"type": "uint256"
-				},
-				{
-					"indexed": true,
-					"internalType": "address",
-					"name": "to",
-					"type": "address"
-				},
-				{
-					"indexed": false,
-					"internalType": "uint256",
-					"name": "amount",
-					"type": "uint256"
-				}
-			],
-			"name": "mintNativeToken",
-			"type": "event"
-		},
-		{
-			"inputs": [
-				{
-					"internalType": "address",
-					"name": "owner",
-					"type": "address"
-				}
-			],
-			"name": "balanceOf",
-			"outputs": [
-				{
-					"internalType": "uint256",
-					"name": "",
-					"type": "uint256"
-				}
-			],
-			"stateMutability": "view",
-			"type": "function"
-		},
-		{
-			"inputs": [],
-			"name": "blockConfirms",
-			"outputs": [
-				{
-					"internalType": "uint256",
-					"name": "",
-					"type": "uint256"
-				}
-			],
-			"stateMutability": "view",
-			"type": "function"
-		},
-		{
-			"inputs": [
-				{
-					"internalType": "bytes32",
-					"name": "",
-					"type": "bytes32"
-				},
-				{
-					"internalType": "uint256",
-					"name": "",
-					"type": "uint256"
-				}
-			],
-			"name": "burnLogUsed",
-			"outputs": [
-				{
-					"internalType": "bool",
-					"name": "",
-					"type": "bool"
-				}
-			],
-			"stateMutability": "view",
-			"type": "function"
-		},
-		{
-			"inputs": [],
-			"name": "burnNative",
-			"outputs": [],
-			"stateMutability": "payable",
-			"type": "function"
-		},
-		{
-			"inputs": [],
-			"name": "burnNonce",
-			"outputs": [
-				{
-					"internalType": "uint256",
-					"name": "",
-					"type": "uint256"
-				}
-			],
-			"stateMutability": "view",
-			"type": "function"
-		},
-		{
-			"inputs": [],
-			"name": "crossChainCallContract",
-			"outputs": [
-				{
-					"internalType": "address",
-					"name": "",
-					"type": "address"
-				}
-			],
-			"stateMutability": "view",
-			"type": "function"
-		},
-		{
-			"inputs": [
-				{
-					"internalType": "uint256",
-					"name": "chainId",
-					"type": "uint256"
-				},
-				{
-					"internalType": "bytes32",
-					"name": "txHash",
-					"type": "bytes32"
-				},
-				{
-					"internalType": "uint256",
-					"name": "logIdx",
-					"type": "uint256"
-				},
-				{
-					"internalType": "uint256",
-					"name": "maxDataLen",
-					"type": "uint256"
-				},
-				{
-					"internalType": "uint256",
-					"name": "confirms",
-					"type": "uint256"
-				}
-			],
-			"name": "getEthereumLog",
-			"outputs": [
-				{
-					"internalType": "address",
-					"name": "",
-					"type": "address"
-				},
-				{
-					"internalType": "bytes32[]",
-					"name": "",
-					"type": "bytes32[]"
-				},
-				{
-					"internalType": "bytes",
-					"name": "",
-					"type": "bytes"
-				}
-			],
-			"stateMutability": "nonpayable",
-			"type": "function"
-		},
-		{
-			"inputs": [
-				{
-					"internalType": "bytes32",
-					"name": "txHash",
-					"type": "bytes32"
-				},
-				{
-					"internalType": "uint256",
-					"name": "logIdx",
-					"type": "uint256"
-				}
-			],
-			"name": "mintNative",
-			"outputs": [],
-			"stateMutability": "nonpayable",
-			"type": "function"
-		},
-		{
-			"inputs": [
-				{
-					"internalType": "address",
-					"name": "to",
-					"type": "address"
-				},
-				{
-					"internalType": "uint256",
-					"name": "amount",
-					"type": "uint256"
-				}
-			],
-			"name": "mintNativeTest",
-			"outputs": [],
-			"stateMutability": "nonpayable",
-			"type": "function"
-		},
-		{
-			"inputs": [
-				{
-					"internalType": "address",
-					"name": "addr",
-					"type": "address"
-				}
-			],
-			"name": "setW3qErc20Addr",
-			"outputs": [],
-			"stateMutability": "nonpayable",
-			"type": "function"
-		},
-		{
-			"inputs": [],
-			"name": "systemOptIn",
-			"outputs": [
-				{
-					"internalType": "address",
-					"name": "",
-					"type": "address"
-				}
-			],
-			"stateMutability": "view",
-			"type": "function"
-		},
-		{
-			"inputs": [],
-			"name": "w3qErc20Addr",
-			"outputs": [
-				{
-					"internalType": "address",
-					"name": "",
-					"type": "address"
-				}
-			],
-			"stateMutability": "view",
-			"type": "function"
-		}
-	]`
+import (
+	_ "embed"
+)
+
+var (
+	//go:embed abis/W3qERC20.json
+	W3qERC20ABI string
+
+	//go:embed abis/W3qNativeTest.json
+	W3qNativeTestABI string
 )
@@ -0,0 +1,108 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// loadAbi returns the abi json of the contract from, in order of precedence, the inline abi, the abiFile or the
+// builtin abi embedded for the contract name
+func (c *ContractConfig) loadAbi() ([]byte, error) {
+	if len(c.Abi) != 0 {
+		return c.Abi, nil
+	}
+
+	if c.AbiFile == "" {
+		return builtinAbi(c.Name)
+	}
+
+	b, err := ioutil.ReadFile(c.AbiFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the abi file of contract [%s]: %w", c.Name, err)
+	}
+
+	artifactName := c.ArtifactName
+	if artifactName == "" {
+		artifactName = c.Name
+	}
+	abiJson, err := extractAbi(b, artifactName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract the abi of contract [%s] from %s: %w", c.Name, c.AbiFile, err)
+	}
+	return abiJson, nil
+}
+
+// extractAbi accepts a plain abi json array, a Hardhat/Foundry/Truffle artifact carrying an "abi" field, or solc
+// --combined-json / standard-json output, from which the contract artifactName is picked
+func extractAbi(b []byte, artifactName string) ([]byte, error) {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		return nil, fmt.Errorf("empty abi file")
+	}
+	if b[0] == '[' {
+		return b, nil
+	}
+
+	var artifact struct {
+		Abi       json.RawMessage            `json:"abi"`
+		Contracts map[string]json.RawMessage `json:"contracts"`
+	}
+	if err := json.Unmarshal(b, &artifact); err != nil {
+		return nil, err
+	}
+
+	if len(artifact.Abi) != 0 {
+		return unquoteAbi(artifact.Abi)
+	}
+
+	if artifact.Contracts == nil {
+		return nil, fmt.Errorf("neither abi nor contracts field exists")
+	}
+
+	for key, raw := range artifact.Contracts {
+		// solc --combined-json: {"contracts": {"path/File.sol:Name": {"abi": ...}}}
+		if strings.Contains(key, ":") {
+			if key[strings.LastIndex(key, ":")+1:] != artifactName {
+				continue
+			}
+			var contract struct {
+				Abi json.RawMessage `json:"abi"`
+			}
+			if err := json.Unmarshal(raw, &contract); err != nil {
+				return nil, err
+			}
+			return unquoteAbi(contract.Abi)
+		}
+
+		// solc standard-json: {"contracts": {"path/File.sol": {"Name": {"abi": [...]}}}}
+		var file map[string]struct {
+			Abi json.RawMessage `json:"abi"`
+		}
+		if err := json.Unmarshal(raw, &file); err != nil {
+			return nil, err
+		}
+		if contract, ok := file[artifactName]; ok {
+			return unquoteAbi(contract.Abi)
+		}
+	}
+	return nil, fmt.Errorf("contract [%s] no exist at the artifact", artifactName)
+}
+
+// unquoteAbi handles the older solc outputs which carry the abi as a json encoded string
+func unquoteAbi(raw json.RawMessage) ([]byte, error) {
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("empty abi")
+	}
+	if raw[0] != '"' {
+		return raw, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
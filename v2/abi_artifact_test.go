@@ -0,0 +1,48 @@
+package v2
+
+import (
+	"bytes"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"strconv"
+	"testing"
+)
+
+func TestExtractAbi(t *testing.T) {
+	const plain = `[{"type":"function","name":"blockExist","inputs":[{"name":"n","type":"uint256"}],"outputs":[{"name":"","type":"bool"}],"stateMutability":"view"}]`
+
+	cases := map[string]string{
+		"plain":         plain,
+		"hardhat":       `{"_format":"hh-sol-artifact-1","contractName":"LightClient","abi":` + plain + `,"bytecode":"0x"}`,
+		"foundry":       `{"abi":` + plain + `,"bytecode":{"object":"0x"},"deployedBytecode":{"object":"0x"}}`,
+		"combined-json": `{"contracts":{"contracts/Other.sol:Other":{"abi":"[]"},"contracts/LightClient.sol:LightClient":{"abi":` + strconv.Quote(plain) + `}},"version":"0.8.4"}`,
+		"standard-json": `{"contracts":{"contracts/LightClient.sol":{"LightClient":{"abi":` + plain + `}}},"sources":{}}`,
+	}
+
+	for name, artifact := range cases {
+		b, err := extractAbi([]byte(artifact), "LightClient")
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		cabi, err := abi.JSON(bytes.NewReader(b))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if _, ok := cabi.Methods[BlockExistFunc]; !ok {
+			t.Errorf("%s: method %s missing", name, BlockExistFunc)
+		}
+	}
+
+	if _, err := extractAbi([]byte(cases["standard-json"]), "Missing"); err == nil {
+		t.Error("expected error for missing artifact")
+	}
+}
+
+func TestBuiltinAbis(t *testing.T) {
+	for _, name := range []string{LightClientContract, EthereumBridgeContract, Web3qBridgeContract} {
+		if _, err := (&ContractConfig{Name: name}).loadAbi(); err != nil {
+			t.Error(err)
+		}
+	}
+}
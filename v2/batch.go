@@ -0,0 +1,133 @@
+package v2
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"sync"
+)
+
+var errNoRpcClient = errors.New("chain client without rpc client for batch calls")
+
+// batchCall sends the elems in JSON-RPC batches of batchSize over the ws endpoint, with up to batchConcurrency
+// batches in flight. It fails with the first error of a batch or of an elem.
+func (c *EthChainRelayer) batchCall(elems []rpc.BatchElem, priority Priority) error {
+	size, concurrency := c.ChainConfig.batchSize, c.ChainConfig.batchConcurrency
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	var firstErr error
+	sem := make(chan struct{}, concurrency)
+	for start := 0; start < len(elems); start += size {
+		end := start + size
+		if end > len(elems) {
+			end = len(elems)
+		}
+		batch := elems[start:end]
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			client, err := c.limitedWsClient(priority, len(batch))
+			if err == nil {
+				if rpcClient := c.chainClient.RpcClient(client); rpcClient == nil {
+					err = errNoRpcClient
+				} else {
+					err = c.report(client, rpcClient.BatchCallContext(c.ctx, batch))
+				}
+			}
+			if err != nil {
+				lock.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+
+	for _, elem := range elems {
+		if elem.Error != nil {
+			return fmt.Errorf("%s %v: %w", elem.Method, elem.Args, elem.Error)
+		}
+	}
+	return nil
+}
+
+// HeadersByRange returns the headers of the blocks [from, to], fetched in batches
+func (c *EthChainRelayer) HeadersByRange(from, to uint64) ([]*types.Header, error) {
+	if to < from {
+		return nil, nil
+	}
+	headers := make([]*types.Header, to-from+1)
+	elems := make([]rpc.BatchElem, len(headers))
+	for i := range elems {
+		elems[i] = rpc.BatchElem{Method: "eth_getBlockByNumber", Args: []interface{}{hexutil.EncodeUint64(from + uint64(i)), false}, Result: &headers[i]}
+	}
+	if err := c.batchCall(elems, PriorityBulk); err != nil {
+		return nil, err
+	}
+	for i, header := range headers {
+		if header == nil {
+			return nil, fmt.Errorf("header %d: %w", from+uint64(i), ethereum.NotFound)
+		}
+	}
+	return headers, nil
+}
+
+// LogsByRange returns the logs of the contract event emitted in the blocks [from, to], querying eth_getLogs for
+// every BackfillBlockRange blocks in batches
+func (c *EthChainRelayer) LogsByRange(contract common.Address, eventId common.Hash, from, to uint64) ([]types.Log, error) {
+	if to < from {
+		return nil, nil
+	}
+	var ranges [][]types.Log
+	var elems []rpc.BatchElem
+	for start := from; start <= to; start += BackfillBlockRange {
+		end := start + BackfillBlockRange - 1
+		if end > to {
+			end = to
+		}
+		filter := map[string]interface{}{
+			"fromBlock": hexutil.EncodeUint64(start),
+			"toBlock":   hexutil.EncodeUint64(end),
+			"address":   []common.Address{contract},
+			"topics":    [][]common.Hash{{eventId}},
+		}
+		ranges = append(ranges, nil)
+		elems = append(elems, rpc.BatchElem{Method: "eth_getLogs", Args: []interface{}{filter}})
+		if end == to {
+			break
+		}
+	}
+	for i := range elems {
+		elems[i].Result = &ranges[i]
+	}
+	if err := c.batchCall(elems, PriorityBulk); err != nil {
+		return nil, err
+	}
+
+	var logs []types.Log
+	for _, r := range ranges {
+		logs = append(logs, r...)
+	}
+	return logs, nil
+}
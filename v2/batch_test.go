@@ -0,0 +1,42 @@
+package v2
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"math/big"
+	"testing"
+)
+
+func TestBatchFetch(t *testing.T) {
+	f := newFakeEth(5)
+	contract, eventId := common.HexToAddress("0x01"), common.HexToHash("0x02")
+	for i := 0; i < 10; i++ {
+		f.mine(types.Log{Address: contract, Topics: []common.Hash{eventId}, Data: []byte{byte(i)}, TxHash: common.BigToHash(big.NewInt(int64(i + 1)))})
+	}
+	relayer := newPollingRelayer(t, f)
+	relayer.ChainConfig.batchSize, relayer.ChainConfig.batchConcurrency = 3, 2
+
+	headers, err := relayer.HeadersByRange(2, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(headers) != 8 {
+		t.Fatalf("got %d headers, want 8", len(headers))
+	}
+	for i, header := range headers {
+		if header.Hash() != f.headers[2+i].Hash() {
+			t.Fatalf("header %d differs", 2+i)
+		}
+	}
+	if _, err = relayer.HeadersByRange(9, 11); err == nil {
+		t.Fatal("headers beyond the head fetched")
+	}
+
+	logs, err := relayer.LogsByRange(contract, eventId, 3, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 8 || logs[0].BlockNumber != 3 || logs[7].BlockNumber != 10 {
+		t.Fatalf("unexpected logs %+v", logs)
+	}
+}
@@ -1,37 +1,284 @@
 package v2
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/ethereum/go-ethereum/common"
+	"time"
 )
 
-var (
-	Web3qChainConf = &ChainConfig{
-		chainId:    3333,
-		httpRpc:    "http://127.0.0.1:8545",
-		wssRpc:     "ws://127.0.0.1:8546",
-		bridgeAddr: common.HexToAddress("0x0000000000000000000000000000000003330002"),
-		leveldbDir: "./ldb-w3q",
+const (
+	Web3qChainName    = "web3q"
+	EthereumChainName = "ethereum"
+)
+
+const (
+	// SubscribeMonitor follows the heads and logs of a chain with eth_subscribe, it needs wssRpc
+	SubscribeMonitor = "subscribe"
+	// PollMonitor follows the heads and logs of a chain with eth_blockNumber and eth_getLogs every pollInterval
+	PollMonitor = "poll"
+
+	DefaultPollInterval        = 5 * time.Second
+	DefaultHealthCheckInterval = 15 * time.Second
+	DefaultBatchSize           = 100
+	DefaultBatchConcurrency    = 4
+)
+
+// rpcUrls is decoded from a single url or a list of urls
+type rpcUrls []string
+
+func (u *rpcUrls) UnmarshalJSON(input []byte) error {
+	var url string
+	if err := json.Unmarshal(input, &url); err == nil {
+		*u = nil
+		if url != "" {
+			*u = rpcUrls{url}
+		}
+		return nil
+	}
+	var urls []string
+	if err := json.Unmarshal(input, &urls); err != nil {
+		return errors.New("rpc endpoint is neither a url nor a list of urls")
 	}
+	*u = urls
+	return nil
+}
 
-	EthereumChainConf = &ChainConfig{
-		chainId:         5,
-		httpRpc:         "https://goerli.infura.io/v3/91ee2301e9004952b8cecaae307b1c28",
-		wssRpc:          "wss://goerli.infura.io/ws/v3/91ee2301e9004952b8cecaae307b1c28",
-		bridgeAddr:      common.HexToAddress("0x0C31d8aCF362353622F16F24A576a310A75312FA"),
-		lightClientAddr: common.HexToAddress("0xCb101a3fEe489E8ef3E713F8085d241849bf8382"),
-		leveldbDir:      "./ldb-eth",
+func (u rpcUrls) MarshalJSON() ([]byte, error) {
+	if len(u) == 1 {
+		return json.Marshal(u[0])
 	}
-)
+	return json.Marshal([]string(u))
+}
 
 type ChainConfig struct {
-	chainId         uint64
-	httpRpc         string
-	wssRpc          string
+	name    string
+	chainId uint64
+	// family names the ChainFamily of the chain, it defaults to web3q for the chain named web3q and ethereum otherwise
+	family string
+	// httpRpcs and wssRpcs are the endpoints of the chain, the healthiest one serves the calls
+	httpRpcs        []string
+	wssRpcs         []string
 	bridgeAddr      common.Address
 	lightClientAddr common.Address
-	leveldbDir      string
+	// headerCacheDepth is the number of recent headers kept by the header store
+	headerCacheDepth uint64
+	// monitor is SubscribeMonitor or PollMonitor, it defaults to SubscribeMonitor if wssRpc is set
+	monitor      string
+	pollInterval time.Duration
+	// healthCheckInterval is the period of the endpoint health probes
+	healthCheckInterval time.Duration
+	// rateLimit limits the requests to each endpoint of the chain, nil means no limit
+	rateLimit *RateLimitConfig
+	// batchSize is the number of requests of a JSON-RPC batch, batchConcurrency the number of batches in flight
+	batchSize        int
+	batchConcurrency int
+	// quorum, if set, confirms the observed logs with independent providers
+	quorum *QuorumConfig
+	// finality decides when a block is final, it defaults by family
+	finality *FinalityConfig
+}
+
+type chainConfigJSON struct {
+	Name             string           `json:"name"`
+	ChainId          uint64           `json:"chainId"`
+	Family           string           `json:"family,omitempty"`
+	HttpRpc          rpcUrls          `json:"httpRpc"`
+	WssRpc           rpcUrls          `json:"wssRpc"`
+	BridgeAddr       common.Address   `json:"bridgeAddr"`
+	LightClientAddr  common.Address   `json:"lightClientAddr"`
+	HeaderCacheDepth uint64           `json:"headerCacheDepth,omitempty"`
+	Monitor          string           `json:"monitor,omitempty"`
+	PollInterval     string           `json:"pollInterval,omitempty"`
+	HealthCheck      string           `json:"healthCheckInterval,omitempty"`
+	Quorum           *QuorumConfig    `json:"quorum,omitempty"`
+	RateLimit        *RateLimitConfig `json:"rateLimit,omitempty"`
+	BatchSize        int              `json:"batchSize,omitempty"`
+	BatchConcurrency int              `json:"batchConcurrency,omitempty"`
+	Finality         *FinalityConfig  `json:"finality,omitempty"`
 }
 
 func NewChainConfig(httpUrl string, wsUrl string, logLevel int) *ChainConfig {
-	return &ChainConfig{httpRpc: httpUrl, wssRpc: wsUrl}
+	monitor := PollMonitor
+	if wsUrl != "" {
+		monitor = SubscribeMonitor
+	}
+	conf := &ChainConfig{family: EthereumFamily, monitor: monitor, pollInterval: DefaultPollInterval, healthCheckInterval: DefaultHealthCheckInterval,
+		batchSize: DefaultBatchSize, batchConcurrency: DefaultBatchConcurrency}
+	if httpUrl != "" {
+		conf.httpRpcs = []string{httpUrl}
+	}
+	if wsUrl != "" {
+		conf.wssRpcs = []string{wsUrl}
+	}
+	return conf
+}
+
+func (c *ChainConfig) Name() string {
+	return c.name
+}
+
+func (c *ChainConfig) ChainId() uint64 {
+	return c.chainId
+}
+
+// Finality is the finality policy of the chain, the default of its family unless configured
+func (c *ChainConfig) Finality() *FinalityConfig {
+	if c.finality == nil {
+		return defaultFinality(c.family)
+	}
+	return c.finality
+}
+
+// PollInterval is the period of the polls of the heads and logs of the chain by PollMonitor
+func (c *ChainConfig) PollInterval() time.Duration {
+	if c.pollInterval <= 0 {
+		return DefaultPollInterval
+	}
+	return c.pollInterval
+}
+
+func (c *ChainConfig) UnmarshalJSON(input []byte) error {
+	var dec chainConfigJSON
+	d := json.NewDecoder(bytes.NewReader(input))
+	d.DisallowUnknownFields()
+	if err := d.Decode(&dec); err != nil {
+		return err
+	}
+	pollInterval := DefaultPollInterval
+	if dec.PollInterval != "" {
+		var err error
+		if pollInterval, err = time.ParseDuration(dec.PollInterval); err != nil {
+			return fmt.Errorf("chain [%s] with invalid pollInterval: %w", dec.Name, err)
+		}
+	}
+	healthCheckInterval := DefaultHealthCheckInterval
+	if dec.HealthCheck != "" {
+		var err error
+		if healthCheckInterval, err = time.ParseDuration(dec.HealthCheck); err != nil {
+			return fmt.Errorf("chain [%s] with invalid healthCheckInterval: %w", dec.Name, err)
+		}
+	}
+	batchSize, batchConcurrency := dec.BatchSize, dec.BatchConcurrency
+	if batchSize == 0 {
+		batchSize = DefaultBatchSize
+	}
+	if batchConcurrency == 0 {
+		batchConcurrency = DefaultBatchConcurrency
+	}
+	family := dec.Family
+	if family == "" {
+		family = EthereumFamily
+		if dec.Name == Web3qChainName {
+			family = Web3qFamily
+		}
+	}
+	finality := dec.Finality
+	if finality == nil {
+		finality = defaultFinality(family)
+	} else if finality.Policy == ConfirmationsFinality && finality.Confirmations == 0 {
+		finality.Confirmations = DefaultConfirmations
+	}
+	monitor := dec.Monitor
+	if monitor == "" {
+		monitor = PollMonitor
+		if len(dec.WssRpc) != 0 {
+			monitor = SubscribeMonitor
+		}
+	}
+
+	*c = ChainConfig{
+		name:                dec.Name,
+		chainId:             dec.ChainId,
+		family:              family,
+		httpRpcs:            dec.HttpRpc,
+		wssRpcs:             dec.WssRpc,
+		bridgeAddr:          dec.BridgeAddr,
+		lightClientAddr:     dec.LightClientAddr,
+		headerCacheDepth:    dec.HeaderCacheDepth,
+		monitor:             monitor,
+		pollInterval:        pollInterval,
+		healthCheckInterval: healthCheckInterval,
+		quorum:              dec.Quorum,
+		rateLimit:           dec.RateLimit,
+		batchSize:           batchSize,
+		batchConcurrency:    batchConcurrency,
+		finality:            finality,
+	}
+	return nil
+}
+
+func (c *ChainConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&chainConfigJSON{
+		Name:             c.name,
+		ChainId:          c.chainId,
+		Family:           c.family,
+		HttpRpc:          c.httpRpcs,
+		WssRpc:           c.wssRpcs,
+		BridgeAddr:       c.bridgeAddr,
+		LightClientAddr:  c.lightClientAddr,
+		HeaderCacheDepth: c.headerCacheDepth,
+		Monitor:          c.monitor,
+		PollInterval:     c.pollInterval.String(),
+		HealthCheck:      c.healthCheckInterval.String(),
+		Quorum:           c.quorum,
+		RateLimit:        c.rateLimit,
+		BatchSize:        c.batchSize,
+		BatchConcurrency: c.batchConcurrency,
+		Finality:         c.finality,
+	})
+}
+
+func (c *ChainConfig) validate() error {
+	if c.name == "" {
+		return errors.New("chain name is empty")
+	}
+	if c.chainId == 0 {
+		return fmt.Errorf("chain [%s] with empty chainId", c.name)
+	}
+	if GetChainFamily(c.family) == nil {
+		return fmt.Errorf("chain [%s] with unknown family [%s]", c.name, c.family)
+	}
+	if len(c.httpRpcs) == 0 && len(c.wssRpcs) == 0 {
+		return fmt.Errorf("chain [%s] with neither httpRpc nor wssRpc", c.name)
+	}
+	for _, url := range append(append([]string{}, c.httpRpcs...), c.wssRpcs...) {
+		if url == "" {
+			return fmt.Errorf("chain [%s] with empty rpc url", c.name)
+		}
+	}
+	if c.batchSize <= 0 || c.batchConcurrency <= 0 {
+		return fmt.Errorf("chain [%s] with non-positive batchSize or batchConcurrency", c.name)
+	}
+	if c.healthCheckInterval <= 0 {
+		return fmt.Errorf("chain [%s] with non-positive healthCheckInterval", c.name)
+	}
+	if c.quorum != nil {
+		if err := c.quorum.validate(); err != nil {
+			return fmt.Errorf("chain [%s]: %w", c.name, err)
+		}
+	}
+	if err := c.Finality().validate(); err != nil {
+		return fmt.Errorf("chain [%s]: %w", c.name, err)
+	}
+	if c.rateLimit != nil {
+		if err := c.rateLimit.validate(); err != nil {
+			return fmt.Errorf("chain [%s] rateLimit: %w", c.name, err)
+		}
+	}
+	switch c.monitor {
+	case SubscribeMonitor:
+		if len(c.wssRpcs) == 0 {
+			return fmt.Errorf("chain [%s] monitored by subscription without wssRpc", c.name)
+		}
+	case PollMonitor:
+		if c.pollInterval <= 0 {
+			return fmt.Errorf("chain [%s] with non-positive pollInterval", c.name)
+		}
+	default:
+		return fmt.Errorf("chain [%s] with unknown monitor [%s]", c.name, c.monitor)
+	}
+	return nil
 }
@@ -0,0 +1,144 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rlp"
+	"math/big"
+	"sync"
+)
+
+const (
+	EthereumFamily = "ethereum"
+	Web3qFamily    = "web3q"
+)
+
+// ChainFamily holds the behaviour that differs between the kinds of evm chains, so that one relayer serves them all
+type ChainFamily interface {
+	Name() string
+	// PackHeader encodes a header of the chain for the light client of another chain, commit is nil if the family
+	// has no commit apart from the header
+	PackHeader(header *types.Header) (encoded []byte, commit []byte, err error)
+	// ReceiptProof returns the merkle proof of the receipt of the tx
+	ReceiptProof(ctx context.Context, client *ethclient.Client, txHash common.Hash) (*ethclient.ReceiptProofData, error)
+	// SuggestFees returns the gasTipCap and gasFeeCap of a new tx
+	SuggestFees(ctx context.Context, client *ethclient.Client) (gasTipCap *big.Int, gasFeeCap *big.Int, err error)
+}
+
+var (
+	familiesLock sync.RWMutex
+	families     = map[string]ChainFamily{
+		EthereumFamily: ethereumFamily{},
+		Web3qFamily:    web3qFamily{},
+	}
+)
+
+// RegisterChainFamily makes a family available to the family field of the chain configs
+func RegisterChainFamily(f ChainFamily) error {
+	familiesLock.Lock()
+	defer familiesLock.Unlock()
+	if _, ok := families[f.Name()]; ok {
+		return fmt.Errorf("chain family [%s] already registered", f.Name())
+	}
+	families[f.Name()] = f
+	return nil
+}
+
+// GetChainFamily returns the registered family of the name, or nil if there is none
+func GetChainFamily(name string) ChainFamily {
+	familiesLock.RLock()
+	defer familiesLock.RUnlock()
+	return families[name]
+}
+
+// ethereumFamily is a london chain: headers are plain rlp, fees follow EIP-1559 and the nodes serve no receipt proofs
+type ethereumFamily struct{}
+
+func (ethereumFamily) Name() string {
+	return EthereumFamily
+}
+
+func (ethereumFamily) PackHeader(header *types.Header) ([]byte, []byte, error) {
+	encoded, err := rlp.EncodeToBytes(header)
+	return encoded, nil, err
+}
+
+var errNoReceiptProof = errors.New("receipt proofs are not served by the chain family")
+
+func (ethereumFamily) ReceiptProof(ctx context.Context, client *ethclient.Client, txHash common.Hash) (*ethclient.ReceiptProofData, error) {
+	return nil, errNoReceiptProof
+}
+
+func (ethereumFamily) SuggestFees(ctx context.Context, client *ethclient.Client) (*big.Int, *big.Int, error) {
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if head.BaseFee == nil {
+		return nil, nil, fmt.Errorf("header %d without base fee", head.Number.Uint64())
+	}
+	return londonFees(ctx, client, head)
+}
+
+// londonFees pays the suggested tip on top of twice the base fee of the head, so that the tx stays includable for a
+// few blocks of rising base fee
+func londonFees(ctx context.Context, client *ethclient.Client, head *types.Header) (*big.Int, *big.Int, error) {
+	gasTipCap, err := client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	gasFeeCap := new(big.Int).Mul(head.BaseFee, big.NewInt(2))
+	gasFeeCap.Add(gasFeeCap, gasTipCap)
+	return gasTipCap, gasFeeCap, nil
+}
+
+// web3qFamily signs its headers with a tendermint commit kept apart from the header rlp, serves receipt proofs by
+// eth_getReceiptProof, and prices gas by eth_gasPrice until its heads carry a base fee
+type web3qFamily struct{}
+
+func (web3qFamily) Name() string {
+	return Web3qFamily
+}
+
+func (web3qFamily) PackHeader(header *types.Header) ([]byte, []byte, error) {
+	return PackedWeb3qHeader(header)
+}
+
+func (web3qFamily) ReceiptProof(ctx context.Context, client *ethclient.Client, txHash common.Hash) (*ethclient.ReceiptProofData, error) {
+	return client.ReceiptProof(ctx, txHash)
+}
+
+func (web3qFamily) SuggestFees(ctx context.Context, client *ethclient.Client) (*big.Int, *big.Int, error) {
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if head.BaseFee != nil {
+		return londonFees(ctx, client, head)
+	}
+	gasPrice, err := client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return gasPrice, new(big.Int).Set(gasPrice), nil
+}
+
+// PackedWeb3qHeader encodes the header without its commit, and the commit
+func PackedWeb3qHeader(header *types.Header) ([]byte, []byte, error) {
+	cph := types.CopyHeader(header)
+	cph.Commit = nil
+	eHeader, err := rlp.EncodeToBytes(cph)
+	if err != nil {
+		return nil, nil, err
+	}
+	eCommit, err := rlp.EncodeToBytes(header.Commit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return eHeader, eCommit, nil
+}
@@ -0,0 +1,34 @@
+package v2
+
+import (
+	"bytes"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"math/big"
+	"testing"
+)
+
+func TestChainFamilyPackHeader(t *testing.T) {
+	header := &types.Header{Number: big.NewInt(7), Difficulty: big.NewInt(1), Extra: []byte{1}}
+
+	encoded, commit, err := GetChainFamily(EthereumFamily).PackHeader(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := new(types.Header)
+	if err = rlp.DecodeBytes(encoded, decoded); err != nil || decoded.Hash() != header.Hash() || commit != nil {
+		t.Fatalf("ethereum header packed as %x %x, err %v", encoded, commit, err)
+	}
+
+	w3qEncoded, w3qCommit, err := GetChainFamily(Web3qFamily).PackHeader(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(w3qEncoded, encoded) || w3qCommit == nil {
+		t.Fatalf("web3q header packed as %x %x", w3qEncoded, w3qCommit)
+	}
+
+	if err = RegisterChainFamily(web3qFamily{}); err == nil {
+		t.Fatal("family registered twice")
+	}
+}
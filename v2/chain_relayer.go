@@ -5,6 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"math/big"
+	"sync"
+	"time"
 )
 
 const (
@@ -18,135 +22,152 @@ type IChainRelayer interface {
 	SendMonitorTask(task IMonitorTask) error
 	Start() error
 	Stop() error
+	Close() error
 }
 
 type IChainClient interface {
 	ChainId() uint64
-	EthWsClient() *ethclient.Client
-	EthHttpClient() *ethclient.Client
-	Web3qWsClient() *ethclient.Client
-	Web3qHttpClient() *ethclient.Client
+	WsClient() *ethclient.Client
+	HttpClient() *ethclient.Client
+	// Redial replaces the ws client with a new connection, unless stale has been replaced already
+	Redial(ctx context.Context, stale *ethclient.Client) error
+	// Limit waits until client may take calls requests of the priority
+	Limit(ctx context.Context, client *ethclient.Client, priority Priority, calls int) error
+	// RpcClient returns the rpc client under client for batch calls, nil if client is not one of the chain client
+	RpcClient(client *ethclient.Client) *rpc.Client
+	// Report records the outcome of a call made with client
+	Report(client *ethclient.Client, err error)
+	Close()
 }
 type EthChainClient struct {
 	chainId    uint64
+	wsUrl      string
 	wsClient   *ethclient.Client
 	httpClient *ethclient.Client
+	wsRpc      *rpc.Client
+	httpRpc    *rpc.Client
+
+	// lock guards wsClient and wsRpc, which are replaced by Redial
+	lock sync.RWMutex
 }
 
 func (e *EthChainClient) ChainId() uint64 {
 	return e.chainId
 }
 
-func (e *EthChainClient) EthWsClient() *ethclient.Client {
+func (e *EthChainClient) WsClient() *ethclient.Client {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
 	return e.wsClient
 }
 
-func (e *EthChainClient) EthHttpClient() *ethclient.Client {
+func (e *EthChainClient) HttpClient() *ethclient.Client {
 	return e.httpClient
 }
 
-func (e *EthChainClient) Web3qWsClient() *ethclient.Client {
-	panic("EthChainClient no implement w3qClient interface")
-}
-
-func (e *EthChainClient) Web3qHttpClient() *ethclient.Client {
-	panic("EthChainClient no implement w3qClient interface")
-}
-
-func (c *EthChainClient) Close() {
-	c.wsClient.Close()
-	c.httpClient.Close()
-}
-
-type Web3qChainClient struct {
-	chainId    uint64
-	wsClient   *ethclient.Client
-	httpClient *ethclient.Client
-}
+// RedialTimeout bounds the dial and chainId check of a redialed ws-client
+var RedialTimeout = 10 * time.Second
 
-func (e *Web3qChainClient) ChainId() uint64 {
-	return e.chainId
-}
-
-func (w *Web3qChainClient) EthWsClient() *ethclient.Client {
-	panic("Web3qChainClient no implement EthChainClient interface")
-}
+// Redial dials outside the lock, so that the callers of WsClient are not held up by an endpoint that hangs
+func (e *EthChainClient) Redial(pctx context.Context, stale *ethclient.Client) error {
+	e.lock.RLock()
+	current := e.wsClient
+	e.lock.RUnlock()
+	if current != stale {
+		return nil
+	}
 
-func (w *Web3qChainClient) EthHttpClient() *ethclient.Client {
-	panic("Web3qChainClient no implement EthChainClient interface")
-}
+	ctx, cancel := context.WithTimeout(pctx, RedialTimeout)
+	defer cancel()
+	wsRpc, err := rpc.DialContext(ctx, e.wsUrl)
+	if err != nil {
+		return err
+	}
+	wsClient := ethclient.NewClient(wsRpc)
+	chainId, err := wsClient.ChainID(ctx)
+	if err != nil {
+		wsClient.Close()
+		return err
+	}
+	if chainId.Uint64() != e.chainId {
+		wsClient.Close()
+		return fmt.Errorf("chainId-%d of the redialed ws-client is different with chainId-%d", chainId.Uint64(), e.chainId)
+	}
 
-func (w *Web3qChainClient) Web3qWsClient() *ethclient.Client {
-	return w.wsClient
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.wsClient != stale {
+		// redialed by another caller meanwhile
+		wsClient.Close()
+		return nil
+	}
+	// the subscriptions left on the stale client fail and resubscribe on the new one
+	e.wsClient.Close()
+	e.wsClient, e.wsRpc = wsClient, wsRpc
+	return nil
+}
+
+// Report is a no-op, the single endpoint is only redialed once its subscriptions drop
+func (e *EthChainClient) Report(client *ethclient.Client, err error) {}
+
+func (e *EthChainClient) RpcClient(client *ethclient.Client) *rpc.Client {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	switch client {
+	case e.wsClient:
+		return e.wsRpc
+	case e.httpClient:
+		return e.httpRpc
+	}
+	return nil
 }
 
-func (w *Web3qChainClient) Web3qHttpClient() *ethclient.Client {
-	return w.httpClient
+// Limit lets every request through, EthChainClient has no rate limit
+func (e *EthChainClient) Limit(ctx context.Context, client *ethclient.Client, priority Priority, calls int) error {
+	return nil
 }
 
-func (c *Web3qChainClient) Close() {
-	c.wsClient.Close()
+func (c *EthChainClient) Close() {
+	c.WsClient().Close()
 	c.httpClient.Close()
 }
 
+// NewEthChainClient dials both urls, if only one is given it serves as both the ws-client and the http-client
 func NewEthChainClient(httpUrl, wsUrl string, ctx context.Context) (*EthChainClient, error) {
 
-	if httpUrl == "" || wsUrl == "" {
+	if httpUrl == "" && wsUrl == "" {
 		return nil, errors.New("httpUrl and wsUrl are empty")
 	}
-
-	var httpClient, wsClient *ethclient.Client
-	var err error
-	if httpUrl != "" {
-		httpClient, err = ethclient.DialContext(ctx, httpUrl)
-		if err != nil {
-			return nil, err
-		}
+	if httpUrl == "" {
+		httpUrl = wsUrl
 	}
-
-	if wsUrl != "" {
-		wsClient, err = ethclient.DialContext(ctx, wsUrl)
-		if err != nil {
-			panic(err)
-			return nil, err
-		}
+	if wsUrl == "" {
+		wsUrl = httpUrl
 	}
-	hchainId, err := httpClient.ChainID(ctx)
+
+	httpRpc, err := rpc.DialContext(ctx, httpUrl)
 	if err != nil {
 		return nil, err
 	}
-	wchainId, err := wsClient.ChainID(ctx)
+	wsRpc, err := rpc.DialContext(ctx, wsUrl)
 	if err != nil {
+		httpRpc.Close()
 		return nil, err
 	}
+	httpClient, wsClient := ethclient.NewClient(httpRpc), ethclient.NewClient(wsRpc)
 
-	if wchainId.Cmp(hchainId) != 0 {
-		return nil, fmt.Errorf("chainId-%d of ws-client is different with chainId-%d of http-client", wchainId.Uint64(), hchainId.Uint64())
-	}
-
-	return &EthChainClient{chainId: wchainId.Uint64(), wsClient: wsClient, httpClient: httpClient}, nil
-}
-
-func NewW3qChainClient(httpUrl, wsUrl string, ctx context.Context) (*Web3qChainClient, error) {
-
-	if httpUrl == "" && wsUrl == "" {
-		return nil, errors.New("httpUrl and wsUrl are empty")
-	}
-
-	var httpClient, wsClient *ethclient.Client
-	var err error
-	if httpUrl != "" {
-		httpClient, err = ethclient.DialContext(ctx, httpUrl)
-		if err != nil {
-			return nil, err
+	hchainId, err := httpClient.ChainID(ctx)
+	if err == nil {
+		var wchainId *big.Int
+		if wchainId, err = wsClient.ChainID(ctx); err == nil && wchainId.Cmp(hchainId) != 0 {
+			err = fmt.Errorf("chainId-%d of ws-client is different with chainId-%d of http-client", wchainId.Uint64(), hchainId.Uint64())
 		}
 	}
-
-	if wsUrl != "" {
-		wsClient, err = ethclient.DialContext(ctx, wsUrl)
-		if err != nil {
-			return nil, err
-		}
+	if err != nil {
+		httpClient.Close()
+		wsClient.Close()
+		return nil, err
 	}
-	return &Web3qChainClient{wsClient: wsClient, httpClient: httpClient}, nil
+
+	return &EthChainClient{chainId: hchainId.Uint64(), wsUrl: wsUrl, wsClient: wsClient, httpClient: httpClient, wsRpc: wsRpc, httpRpc: httpRpc}, nil
 }
@@ -0,0 +1,50 @@
+package v2
+
+import (
+	"encoding/binary"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+var checkpointPrefix = []byte("checkpoint/")
+
+// checkpointKey is checkpoint/<route>/<contract><eventId>
+func checkpointKey(route string, contract common.Address, eventId common.Hash) []byte {
+	key := append([]byte{}, checkpointPrefix...)
+	key = append(key, route...)
+	key = append(key, '/')
+	key = append(key, contract.Bytes()...)
+	return append(key, eventId.Bytes()...)
+}
+
+// CheckpointStore persists per route the last block of a chain whose logs of a contract event are all observed
+type CheckpointStore struct {
+	db Storage
+}
+
+func NewCheckpointStore(db Storage) *CheckpointStore {
+	return &CheckpointStore{db: db}
+}
+
+// Get returns the checkpoint, ok is false if none has been saved
+func (s *CheckpointStore) Get(route string, contract common.Address, eventId common.Hash) (number uint64, ok bool, err error) {
+	key := checkpointKey(route, contract, eventId)
+	has, err := s.db.Has(key)
+	if err != nil || !has {
+		return 0, false, err
+	}
+
+	b, err := s.db.Get(key)
+	if err != nil {
+		return 0, false, err
+	}
+	return binary.BigEndian.Uint64(b), true, nil
+}
+
+func (s *CheckpointStore) Put(route string, contract common.Address, eventId common.Hash, number uint64) error {
+	return s.put(s.db, route, contract, eventId, number)
+}
+
+func (s *CheckpointStore) put(w ethdb.KeyValueWriter, route string, contract common.Address, eventId common.Hash, number uint64) error {
+	return w.Put(checkpointKey(route, contract, eventId), encodeNumber(number))
+}
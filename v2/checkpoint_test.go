@@ -0,0 +1,29 @@
+package v2
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"testing"
+)
+
+func TestCheckpointStore(t *testing.T) {
+	store := NewCheckpointStore(NewMemoryStorage())
+	contract, eventId := common.HexToAddress("0x01"), common.HexToHash("0x02")
+
+	if _, ok, err := store.Get("w3q-to-eth", contract, eventId); err != nil || ok {
+		t.Fatalf("unexpected checkpoint before any is saved: ok %v err %v", ok, err)
+	}
+
+	for _, number := range []uint64{100, 2100} {
+		if err := store.Put("w3q-to-eth", contract, eventId, number); err != nil {
+			t.Fatal(err)
+		}
+		got, ok, err := store.Get("w3q-to-eth", contract, eventId)
+		if err != nil || !ok || got != number {
+			t.Fatalf("checkpoint %d: got %d ok %v err %v", number, got, ok, err)
+		}
+	}
+
+	if _, ok, _ := store.Get("other", contract, eventId); ok {
+		t.Fatal("checkpoint leaks to another route")
+	}
+}
@@ -0,0 +1,168 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// KeyPasswordEnv is read when neither key.password nor key.passwordFile is configured
+const KeyPasswordEnv = "RELAYER_KEY_PASSWORD"
+
+// Config is the deployment description of a relayer process: the chains it connects to,
+// the contracts it talks to, the routes it relays, the storage it keeps its state in and the key it signs with.
+type Config struct {
+	Chains    []*ChainConfig    `json:"chains"`
+	Contracts []*ContractConfig `json:"contracts"`
+	Routes    []*RouteConfig    `json:"routes"`
+	Storage   StorageConfig     `json:"storage"`
+	Key       KeyConfig         `json:"key"`
+}
+
+// ContractConfig takes its abi from the inline abi, else from abiFile (a plain abi json or a Hardhat, Foundry or solc
+// build artifact, relative paths are resolved against the config file), else from the builtin abi of the contract name
+type ContractConfig struct {
+	Name         string          `json:"name"`
+	ChainId      uint64          `json:"chainId"`
+	Address      common.Address  `json:"address"`
+	Abi          json.RawMessage `json:"abi,omitempty"`
+	AbiFile      string          `json:"abiFile,omitempty"`
+	ArtifactName string          `json:"artifactName,omitempty"` // the contract to pick from solc output, defaults to name
+}
+
+type KeyConfig struct {
+	Keystore     string `json:"keystore"`
+	Password     string `json:"password,omitempty"`
+	PasswordFile string `json:"passwordFile,omitempty"`
+}
+
+func LoadConfig(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	cfg := new(Config)
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode config file %s: %w", path, err)
+	}
+
+	if err = cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	for _, contract := range cfg.Contracts {
+		if contract.AbiFile != "" && !filepath.IsAbs(contract.AbiFile) {
+			contract.AbiFile = filepath.Join(filepath.Dir(path), contract.AbiFile)
+		}
+	}
+	return cfg, nil
+}
+
+func (cfg *Config) validate() error {
+	if len(cfg.Chains) == 0 {
+		return errors.New("no chain configured")
+	}
+
+	names := make(map[string]bool)
+	ids := make(map[uint64]bool)
+	for _, chain := range cfg.Chains {
+		if err := chain.validate(); err != nil {
+			return err
+		}
+		if names[chain.name] {
+			return fmt.Errorf("duplicate chain name [%s]", chain.name)
+		}
+		if ids[chain.chainId] {
+			return fmt.Errorf("duplicate chainId [%d]", chain.chainId)
+		}
+		names[chain.name] = true
+		ids[chain.chainId] = true
+	}
+
+	contracts := make(map[string]uint64)
+	for _, contract := range cfg.Contracts {
+		if contract.Name == "" {
+			return errors.New("contract name is empty")
+		}
+		if _, ok := contracts[contract.Name]; ok {
+			return fmt.Errorf("duplicate contract name [%s]", contract.Name)
+		}
+		if len(contract.Abi) != 0 && contract.AbiFile != "" {
+			return fmt.Errorf("contract [%s] with both abi and abiFile", contract.Name)
+		}
+		if !ids[contract.ChainId] {
+			return fmt.Errorf("contract [%s] deployed at unknown chainId [%d]", contract.Name, contract.ChainId)
+		}
+		contracts[contract.Name] = contract.ChainId
+	}
+
+	routes := make(map[string]bool)
+	for _, route := range cfg.Routes {
+		if err := route.validate(contracts); err != nil {
+			return err
+		}
+		if routes[route.Name] {
+			return fmt.Errorf("duplicate route name [%s]", route.Name)
+		}
+		routes[route.Name] = true
+	}
+
+	if err := cfg.Storage.validate(); err != nil {
+		return err
+	}
+
+	if cfg.Key.Keystore == "" {
+		return errors.New("key.keystore is empty")
+	}
+	return nil
+}
+
+// Chain returns the chain configured with the given name, or nil
+func (cfg *Config) Chain(name string) *ChainConfig {
+	for _, chain := range cfg.Chains {
+		if chain.name == name {
+			return chain
+		}
+	}
+	return nil
+}
+
+// ChainById returns the chain configured with the given chainId, or nil
+func (cfg *Config) ChainById(chainId uint64) *ChainConfig {
+	for _, chain := range cfg.Chains {
+		if chain.chainId == chainId {
+			return chain
+		}
+	}
+	return nil
+}
+
+// KeyPassword resolves the keystore password from key.passwordFile, key.password or the RELAYER_KEY_PASSWORD
+// environment variable, in that order
+func (k KeyConfig) KeyPassword() (string, error) {
+	if k.PasswordFile != "" {
+		b, err := ioutil.ReadFile(k.PasswordFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read key password file: %w", err)
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	}
+
+	if k.Password != "" {
+		return k.Password, nil
+	}
+
+	if passwd, ok := os.LookupEnv(KeyPasswordEnv); ok {
+		return passwd, nil
+	}
+	return "", fmt.Errorf("no key password configured, set key.passwordFile, key.password or %s", KeyPasswordEnv)
+}
@@ -0,0 +1,69 @@
+package v2
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	cfg, err := LoadConfig("../config.example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w3q := cfg.Chain(Web3qChainName)
+	if w3q == nil || w3q.chainId != 3333 || w3q.family != Web3qFamily {
+		t.Fatalf("unexpected web3q chain config %+v", w3q)
+	}
+	if eth := cfg.ChainById(5); eth == nil || eth.name != EthereumChainName || eth.family != EthereumFamily {
+		t.Fatalf("unexpected ethereum chain config %+v", eth)
+	}
+	if w3q.Finality().Policy != InstantFinality {
+		t.Fatalf("web3q chain with finality %+v", w3q.Finality())
+	}
+	if f := cfg.ChainById(5).Finality(); f.Policy != FinalizedFinality {
+		t.Fatalf("ethereum chain with finality %+v", f)
+	}
+
+	contracts, err := NewContractsConfig(cfg.Contracts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := contracts.GetContractAbi(LightClientContract).Methods[SubmitHeaderFunc]; !ok {
+		t.Fatalf("builtin abi of %s has no method %s", LightClientContract, SubmitHeaderFunc)
+	}
+	bridge := contracts.GetContractByAddr(w3q.chainId, w3q.bridgeAddr)
+	if bridge == nil {
+		t.Fatalf("no contract at web3q bridge address %s", w3q.bridgeAddr.Hex())
+	}
+	if eventId := bridge.Abi.Events[ETHEventSendTokenName].ID; eventId == (common.Hash{}) {
+		t.Fatalf("no %s event at web3q bridge", ETHEventSendTokenName)
+	}
+}
+
+func TestLoadConfigRejectsInvalid(t *testing.T) {
+	cases := map[string]string{
+		"unknown field":   `{"chains":[{"name":"a","chainId":1,"httpRpc":"http://a","foo":1}],"key":{"keystore":"k"}}`,
+		"duplicate chain": `{"chains":[{"name":"a","chainId":1,"httpRpc":"http://a"},{"name":"a","chainId":2,"httpRpc":"http://b"}],"key":{"keystore":"k"}}`,
+		"unknown chainId": `{"chains":[{"name":"a","chainId":1,"httpRpc":"http://a"}],"contracts":[{"name":"c","chainId":2}],"key":{"keystore":"k"}}`,
+		"route contract":  `{"chains":[{"name":"a","chainId":1,"httpRpc":"http://a"}],"routes":[{"name":"r","source":{"contract":"c","event":"E"},"target":{"contract":"c","method":"m"}}],"key":{"keystore":"k"}}`,
+		"missing key":     `{"chains":[{"name":"a","chainId":1,"httpRpc":"http://a"}]}`,
+		"unknown family":  `{"chains":[{"name":"a","chainId":1,"family":"f","httpRpc":"http://a"}],"key":{"keystore":"k"}}`,
+		"rate limit":      `{"chains":[{"name":"a","chainId":1,"httpRpc":"http://a","rateLimit":{"requestsPerSecond":-1}}],"key":{"keystore":"k"}}`,
+		"quorum":          `{"chains":[{"name":"a","chainId":1,"httpRpc":"http://a","quorum":{"rpcs":["http://b"],"threshold":2}}],"key":{"keystore":"k"}}`,
+		"finality":        `{"chains":[{"name":"a","chainId":1,"httpRpc":"http://a","finality":{"policy":"latest"}}],"key":{"keystore":"k"}}`,
+	}
+
+	dir := t.TempDir()
+	for name, content := range cases {
+		path := filepath.Join(dir, "config.json")
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadConfig(path); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
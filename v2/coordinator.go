@@ -15,57 +15,30 @@ const (
 	CoordinatorStopped = 2
 )
 
-var GlobalCoordinator *Coordinator
-
-func init() {
-	initContractConf()
-	GlobalCoordinator = NewCoordinator(3)
-	web3qRelayer, err := NewEthChainRelayer(
-		GlobalCoordinator.ctx,
-		"/Users/chenyanlong/Work/go-ethereum/cmd/geth/data_val_0/keystore/UTC--2022-06-07T04-15-42.696295000Z--96f22a48dcd4dfb99a11560b24bee02f374ca77d",
-		"123",
-		Web3qChainConf,
-	)
-	if err != nil {
-		panic(err)
-	}
-
-	ethRelayer, err := NewEthChainRelayer(
-		GlobalCoordinator.ctx,
-		"/Users/chenyanlong/Work/go-ethereum/cmd/geth/data_val_0/keystore/UTC--2022-06-07T04-15-42.696295000Z--96f22a48dcd4dfb99a11560b24bee02f374ca77d",
-		"123",
-		EthereumChainConf,
-	)
-	if err != nil {
-		panic(err)
-	}
-
-	GlobalCoordinator.AddChainRelayer(web3qRelayer)
-	GlobalCoordinator.AddChainRelayer(ethRelayer)
-
-	_, err = NewScheduleTaskFromW3qToEth("Schedule task to exec tx on ethereum", GlobalCoordinator.taskManager)
-	if err != nil {
-		panic(err)
-	}
-
-	//eventMonitorTask := GlobalCoordinator.taskManager.GenMonitorEventTask(
-	//	EthereumChainConf.chainId,
-	//	EthereumChainConf.bridgeAddr,
-	//	ETHEventSendTokenName,
-	//)
-	//stx := GlobalCoordinator.taskManager.GenReceiveToken_SubmitTxTask_OnWeb3q()
-	//err = eventMonitorTask.SubscribeData(stx.receiveCh)
-	//if err != nil {
-	//	panic(err)
-	//}
-	//GlobalCoordinator.AddTaskIntoTaskPool(eventMonitorTask)
-	//GlobalCoordinator.AddTaskIntoTaskPool(stx)
+// SetupLogger installs the root log handler of the process with the given verbosity
+func SetupLogger(logLevel int) {
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(logLevel))
+	log.Root().SetHandler(glogger)
+}
 
+type CoordinatorOptions struct {
+	Contracts ContractsConfig
+	// Storage is shared by the chain relayers and closed by Stop
+	Storage Storage
 }
 
 type Coordinator struct {
 	relayers    map[uint64]IChainRelayer
 	taskManager *TaskManager
+	contracts   ContractsConfig
+	cfg         *Config
+	storage     Storage
+	closeOnce   sync.Once
+
+	// lock guards relayers and contracts, reloadLock serializes Reload
+	lock       sync.RWMutex
+	reloadLock sync.Mutex
 
 	status     uint32
 	ctx        context.Context
@@ -77,18 +50,58 @@ type Coordinator struct {
 	log.Logger
 }
 
-func NewCoordinator(logLevel int) *Coordinator {
-
-	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
-	glogger.Verbosity(log.Lvl(logLevel))
-	log.Root().SetHandler(glogger)
-
+func NewCoordinator(opts *CoordinatorOptions) *Coordinator {
 	relayers := make(map[uint64]IChainRelayer)
 	ctx, cf := context.WithCancel(context.Background())
 
+	c := &Coordinator{Logger: log.Root(), ctx: ctx, cancelFunc: cf, relayers: relayers, contracts: opts.Contracts, storage: opts.Storage, status: CoordinatorNoStart, errCh: make(chan error)}
 	// new taskManager
-	taskManager := NewTaskManager(ctx)
-	return &Coordinator{Logger: log.Root(), ctx: ctx, cancelFunc: cf, relayers: relayers, taskManager: taskManager, status: CoordinatorNoStart, wg: sync.WaitGroup{}, errCh: make(chan error)}
+	c.taskManager = NewTaskManager(ctx, c, opts.Contracts)
+	return c
+}
+
+// NewCoordinatorFromConfig builds a coordinator together with its storage, chain relayers and tasks from cfg,
+// a relative storage dir is resolved against dataDir
+func NewCoordinatorFromConfig(cfg *Config, dataDir string) (*Coordinator, error) {
+	contracts, err := NewContractsConfig(cfg.Contracts)
+	if err != nil {
+		return nil, err
+	}
+
+	passwd, err := cfg.Key.KeyPassword()
+	if err != nil {
+		return nil, err
+	}
+
+	storage, err := OpenStorage(cfg.Storage, dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open storage: %w", err)
+	}
+
+	c := NewCoordinator(&CoordinatorOptions{Contracts: contracts, Storage: storage})
+	for _, chainConf := range cfg.Chains {
+		relayer, err := NewEthChainRelayer(c.Context(), cfg.Key.Keystore, passwd, chainConf, contracts, storage)
+		if err != nil {
+			c.Stop()
+			return nil, err
+		}
+		c.AddChainRelayer(relayer)
+	}
+
+	for _, route := range cfg.Routes {
+		if err = c.AddRoute(route); err != nil {
+			c.Stop()
+			return nil, err
+		}
+	}
+
+	c.cfg = cfg
+	return c, nil
+}
+
+// Context is the parent context of the chain relayers and tasks belonging to the coordinator
+func (c *Coordinator) Context() context.Context {
+	return c.ctx
 }
 
 func (c *Coordinator) Start() error {
@@ -104,18 +117,11 @@ func (c *Coordinator) running() error {
 		return fmt.Errorf("Coordinator::Running() with invalid status [%d]", atomic.LoadUint32(&c.status))
 	}
 
+	c.lock.RLock()
 	for _, relayer := range c.relayers {
-		c.wg.Add(1)
-		go func(c *Coordinator, chainRelayer IChainRelayer) {
-			defer c.wg.Done()
-			err := chainRelayer.Start()
-			if err != nil {
-				log.Error("Coordinator::running() failed to start relayer", "chainId", relayer.ChainId(), "err", err.Error())
-				c.errCh <- err
-				return
-			}
-		}(c, relayer)
+		c.startChainRelayer(relayer)
 	}
+	c.lock.RUnlock()
 
 	c.wg.Add(1)
 	go func(c *Coordinator) {
@@ -140,10 +146,24 @@ func (c *Coordinator) running() error {
 			return nil
 		}
 	}
-	return nil
+}
+
+func (c *Coordinator) startChainRelayer(relayer IChainRelayer) {
+	c.wg.Add(1)
+	go func(c *Coordinator, chainRelayer IChainRelayer) {
+		defer c.wg.Done()
+		err := chainRelayer.Start()
+		if err != nil {
+			log.Error("Coordinator::running() failed to start relayer", "chainId", chainRelayer.ChainId(), "err", err.Error())
+			c.errCh <- err
+			return
+		}
+	}(c, relayer)
 }
 
 func (c *Coordinator) GetRelayer(chainId uint64) IChainRelayer {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
 	return c.relayers[chainId]
 }
 
@@ -151,11 +171,33 @@ func (c *Coordinator) Stop() {
 	log.Info("Coordinator::Stop() coordinator send stop-signal")
 	c.cancelFunc()
 	c.wg.Wait()
+
+	c.closeOnce.Do(func() {
+		if c.storage == nil {
+			return
+		}
+		if err := c.storage.Close(); err != nil {
+			log.Error("Coordinator::Stop() failed to close storage", "err", err.Error())
+		}
+	})
+}
+
+// RpcUsage returns the request counters of the rpc endpoints of every chain by chainId
+func (c *Coordinator) RpcUsage() map[uint64][]*EndpointUsage {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	usage := make(map[uint64][]*EndpointUsage)
+	for chainId, relayer := range c.relayers {
+		if r, ok := relayer.(*EthChainRelayer); ok {
+			usage[chainId] = r.RpcUsage()
+		}
+	}
+	return usage
 }
 
 // SendTaskToRelayer will be invoked by taskManager
 func (c *Coordinator) SendTaskToRelayer(task IMonitorTask) error {
-	relayer := c.relayers[task.TargetChainId()]
+	relayer := c.GetRelayer(task.TargetChainId())
 	if relayer == nil {
 		return fmt.Errorf("the chain-relayer corresponding to the task-chainId [%d] no exists", task.TargetChainId())
 	}
@@ -163,12 +205,33 @@ func (c *Coordinator) SendTaskToRelayer(task IMonitorTask) error {
 }
 
 func (c *Coordinator) AddChainRelayer(relayer IChainRelayer) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 	c.relayers[relayer.ChainId()] = relayer
 }
 
-// todo
-func (c *Coordinator) removeChainRelayer() {
+// AddRoute registers the tasks relaying the route declared by conf
+func (c *Coordinator) AddRoute(conf *RouteConfig) error {
+	c.lock.RLock()
+	contracts := c.contracts
+	c.lock.RUnlock()
+
+	route, err := NewRoute(conf, contracts)
+	if err != nil {
+		return err
+	}
+	return c.taskManager.AddRoute(route)
+}
+
+// stopChainRelayer stops the tasks running against the chain before the chain relayer itself
+func (c *Coordinator) stopChainRelayer(chainId uint64) error {
+	relayer := c.GetRelayer(chainId)
+	if relayer == nil {
+		return fmt.Errorf("the chain-relayer [%d] no exists", chainId)
+	}
 
+	c.taskManager.StopTaskBySpecificChainId(chainId)
+	return relayer.Stop()
 }
 
 func (c *Coordinator) AddTaskIntoTaskPool(task Task) {
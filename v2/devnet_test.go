@@ -0,0 +1,401 @@
+package v2
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/asm"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// devnetChain is an in-process node in dev mode: a clique chain sealing a block as soon as a tx is pending, served
+// over http and ws on a random local port
+type devnetChain struct {
+	chainId uint64
+	key     *ecdsa.PrivateKey
+	stack   *node.Node
+	client  *ethclient.Client
+}
+
+// devnetReceiptProofs serves eth_getReceiptProof like the web3q nodes. The proofs carry the receipt key and value
+// without the trie path, the mock bridge does not verify them.
+type devnetReceiptProofs struct {
+	backend *eth.Ethereum
+}
+
+func (p *devnetReceiptProofs) GetReceiptProof(txHash common.Hash) (*ethclient.ReceiptProofData, error) {
+	_, blockHash, _, index := rawdb.ReadTransaction(p.backend.ChainDb(), txHash)
+	if blockHash == (common.Hash{}) {
+		return nil, ethereum.NotFound
+	}
+	receipts := p.backend.BlockChain().GetReceiptsByHash(blockHash)
+	if index >= uint64(len(receipts)) {
+		return nil, ethereum.NotFound
+	}
+	key, err := rlp.EncodeToBytes(uint(index))
+	if err != nil {
+		return nil, err
+	}
+	value, err := receipts[index].MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &ethclient.ReceiptProofData{ReceiptKey: key, ReceiptValue: value}, nil
+}
+
+// startDevnetChain starts a chain sealed by key, the account of key is funded at genesis
+func startDevnetChain(t *testing.T, chainId uint64, key *ecdsa.PrivateKey) *devnetChain {
+	stack, err := node.New(&node.Config{
+		Name:        "devnet",
+		HTTPHost:    "127.0.0.1",
+		HTTPModules: []string{"eth", "net", "web3"},
+		WSHost:      "127.0.0.1",
+		WSModules:   []string{"eth", "net", "web3"},
+		WSOrigins:   []string{"*"},
+		P2P:         p2p.Config{NoDiscovery: true, MaxPeers: 0},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { stack.Close() })
+
+	ks := keystore.NewKeyStore(t.TempDir(), keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.ImportECDSA(key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ks.Unlock(account, ""); err != nil {
+		t.Fatal(err)
+	}
+	stack.AccountManager().AddBackend(ks)
+
+	conf := ethconfig.Defaults
+	conf.NetworkId = chainId
+	conf.Genesis = core.DeveloperGenesisBlock(0, 11500000, account.Address)
+	conf.Genesis.Config.ChainID = new(big.Int).SetUint64(chainId)
+	conf.Miner.Etherbase = account.Address
+	conf.Miner.GasPrice = big.NewInt(1)
+	backend, err := eth.New(stack, &conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stack.RegisterAPIs([]rpc.API{{Namespace: "eth", Version: "1.0", Service: &devnetReceiptProofs{backend}, Public: true}})
+	if err = stack.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err = backend.StartMining(1); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := ethclient.Dial(stack.WSEndpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return &devnetChain{chainId: chainId, key: key, stack: stack, client: client}
+}
+
+// transact sends a tx from the key of the chain and waits for its receipt, to nil deploys data
+func (d *devnetChain) transact(t *testing.T, to *common.Address, value *big.Int, data []byte) *types.Receipt {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	nonce, err := d.client.PendingNonceAt(ctx, crypto.PubkeyToAddress(d.key.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := types.SignNewTx(d.key, types.LatestSignerForChainID(new(big.Int).SetUint64(d.chainId)), &types.DynamicFeeTx{
+		ChainID:   new(big.Int).SetUint64(d.chainId),
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(1e9),
+		GasFeeCap: big.NewInt(10e9),
+		Gas:       1000000,
+		To:        to,
+		Value:     value,
+		Data:      data,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = d.client.SendTransaction(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	receipt, err := bind.WaitMined(ctx, d.client, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("tx %s reverted", tx.Hash().Hex())
+	}
+	return receipt
+}
+
+// deploy creates a contract running the assembled runtime code
+func (d *devnetChain) deploy(t *testing.T, runtime string) common.Address {
+	code := assemble(t, runtime)
+	// copy the runtime code behind the 12 bytes of this constructor to memory and return it
+	constructor := []byte{0x61, byte(len(code) >> 8), byte(len(code)), 0x80, 0x60, 0x0c, 0x60, 0x00, 0x39, 0x60, 0x00, 0xf3}
+	return d.transact(t, nil, nil, append(constructor, code...)).ContractAddress
+}
+
+func assemble(t *testing.T, src string) []byte {
+	compiler := asm.NewCompiler(false)
+	compiler.Feed(asm.Lex([]byte(src), false))
+	code, errs := compiler.Compile()
+	if len(errs) != 0 {
+		t.Fatalf("failed to assemble: %v", errs)
+	}
+	return common.FromHex(code)
+}
+
+// The mock contracts implement the parts of the abis in v2/abis used by the w3q-to-eth route, in evm assembly.
+// Every call starts at the selector dispatch, unknown selectors revert.
+
+// mockWeb3qBridge: sendToEth() emits SendToken(nonce, caller, value) with an increasing nonce
+const mockWeb3qBridge = `
+	PUSH 0
+	CALLDATALOAD
+	PUSH 0xe0
+	SHR
+	PUSH %[1]s
+	EQ
+	JUMPI @sendToEth
+	PUSH 0
+	DUP1
+	REVERT
+sendToEth:
+	PUSH 0
+	SLOAD
+	DUP1
+	PUSH 1
+	ADD
+	PUSH 0
+	SSTORE
+	CALLVALUE
+	PUSH 0
+	MSTORE
+	CALLER
+	SWAP1
+	PUSH %[2]s
+	PUSH 32
+	PUSH 0
+	LOG3
+	STOP
+`
+
+// mockLightClient: submitHeader(height, ...) marks the height as submitted, blockExist(height) tells if it is,
+// getNextEpochHeight() is 0 so that no epoch header is ever due
+const mockLightClient = `
+	PUSH 0
+	CALLDATALOAD
+	PUSH 0xe0
+	SHR
+	DUP1
+	PUSH %[1]s
+	EQ
+	JUMPI @blockExist
+	DUP1
+	PUSH %[2]s
+	EQ
+	JUMPI @submitHeader
+	PUSH %[3]s
+	EQ
+	JUMPI @nextEpoch
+	PUSH 0
+	DUP1
+	REVERT
+blockExist:
+	PUSH 4
+	CALLDATALOAD
+	SLOAD
+	PUSH 0
+	MSTORE
+	PUSH 32
+	PUSH 0
+	RETURN
+submitHeader:
+	PUSH 1
+	PUSH 4
+	CALLDATALOAD
+	SSTORE
+	STOP
+nextEpoch:
+	PUSH 32
+	PUSH 0
+	RETURN
+`
+
+// mockEthereumBridge: receiveFromWeb3q(height, proof, logIdx) reverts unless the light client holds the header
+// of height, then emits ReveiveToken(height, logIdx, caller, 0)
+const mockEthereumBridge = `
+	PUSH 0
+	CALLDATALOAD
+	PUSH 0xe0
+	SHR
+	PUSH %[1]s
+	EQ
+	JUMPI @receive
+	JUMP @fail
+receive:
+	PUSH %[2]s
+	PUSH 0xe0
+	SHL
+	PUSH 0
+	MSTORE
+	PUSH 4
+	CALLDATALOAD
+	PUSH 4
+	MSTORE
+	PUSH 32
+	PUSH 64
+	PUSH 36
+	PUSH 0
+	PUSH %[3]s
+	GAS
+	STATICCALL
+	ISZERO
+	JUMPI @fail
+	PUSH 64
+	MLOAD
+	ISZERO
+	JUMPI @fail
+	CALLER
+	PUSH 68
+	CALLDATALOAD
+	PUSH 4
+	CALLDATALOAD
+	PUSH %[4]s
+	PUSH 32
+	PUSH 96
+	LOG4
+	STOP
+fail:
+	PUSH 0
+	DUP1
+	REVERT
+`
+
+func TestDevnetRelayW3qToEth(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skip the devnet test in short mode")
+	}
+	key, err := crypto.HexToECDSA(testKeyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w3qChain, ethChain := startDevnetChain(t, 3333, key), startDevnetChain(t, 5, key)
+
+	contracts, err := NewContractsConfig([]*ContractConfig{{Name: Web3qBridgeContract}, {Name: LightClientContract}, {Name: EthereumBridgeContract}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w3qBridgeAbi, lightClientAbi, ethBridgeAbi := contracts.GetContractAbi(Web3qBridgeContract), contracts.GetContractAbi(LightClientContract), contracts.GetContractAbi(EthereumBridgeContract)
+	hex := hexutil.Encode
+	w3qBridge := w3qChain.deploy(t, fmt.Sprintf(mockWeb3qBridge, hex(w3qBridgeAbi.Methods["sendToEth"].ID), hex(w3qBridgeAbi.Events[ETHEventSendTokenName].ID.Bytes())))
+	lightClient := ethChain.deploy(t, fmt.Sprintf(mockLightClient, hex(lightClientAbi.Methods[BlockExistFunc].ID), hex(lightClientAbi.Methods[SubmitHeaderFunc].ID), hex(lightClientAbi.Methods[GetNextEpochHeightFunc].ID)))
+	ethBridge := ethChain.deploy(t, fmt.Sprintf(mockEthereumBridge, hex(ethBridgeAbi.Methods[receiveFromWeb3qFunc].ID), hex(lightClientAbi.Methods[BlockExistFunc].ID), hex(lightClient.Bytes()), hex(ethBridgeAbi.Events[ETHEventReveiveTokenName].ID.Bytes())))
+
+	dir := t.TempDir()
+	config := fmt.Sprintf(`{
+  "chains": [
+    {"name": "web3q", "chainId": 3333, "httpRpc": %q, "wssRpc": %q, "bridgeAddr": %q, "pollInterval": "100ms"},
+    {"name": "ethereum", "chainId": 5, "httpRpc": %q, "wssRpc": %q, "bridgeAddr": %q, "lightClientAddr": %q, "pollInterval": "100ms"}
+  ],
+  "contracts": [
+    {"name": "Web3qBridgeContract", "chainId": 3333, "address": %q},
+    {"name": "LightClientContract", "chainId": 5, "address": %q},
+    {"name": "EthereumBridgeContract", "chainId": 5, "address": %q}
+  ],
+  "routes": [
+    {
+      "name": "w3q-to-eth",
+      "source": {"contract": "Web3qBridgeContract", "event": "SendToken", "startBlock": 1},
+      "headerRelay": {"contract": "LightClientContract"},
+      "target": {"contract": "EthereumBridgeContract", "method": "receiveFromWeb3q"},
+      "args": ["log.blockNumber", "receiptProof", "log.index"]
+    }
+  ],
+  "storage": {"engine": "memory"},
+  "key": {"keystore": %q, "password": "devnet"}
+}`, w3qChain.stack.HTTPEndpoint(), w3qChain.stack.WSEndpoint(), w3qBridge.Hex(),
+		ethChain.stack.HTTPEndpoint(), ethChain.stack.WSEndpoint(), ethBridge.Hex(), lightClient.Hex(),
+		w3qBridge.Hex(), lightClient.Hex(), ethBridge.Hex(), writeTestKeystore(t, "devnet"))
+	path := filepath.Join(dir, "config.json")
+	if err = ioutil.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	coordinator, err := NewCoordinatorFromConfig(cfg, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go coordinator.Start()
+	defer coordinator.Stop()
+
+	// burn at web3q, the route backfills from block 1 so the burn is seen however soon it is mined
+	burn := w3qChain.transact(t, &w3qBridge, big.NewInt(100), w3qBridgeAbi.Methods["sendToEth"].ID)
+	if len(burn.Logs) != 1 {
+		t.Fatalf("burn emitted %d logs", len(burn.Logs))
+	}
+	burnLog := burn.Logs[0]
+
+	query := ethereum.FilterQuery{Addresses: []common.Address{ethBridge}, Topics: [][]common.Hash{{ethBridgeAbi.Events[ETHEventReveiveTokenName].ID}}}
+	var received []types.Log
+	for deadline := time.Now().Add(30 * time.Second); len(received) == 0; time.Sleep(100 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("burn not received at ethereum")
+		}
+		if received, err = ethChain.client.FilterLogs(context.Background(), query); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(received) != 1 {
+		t.Fatalf("burn received %d times", len(received))
+	}
+	if height := received[0].Topics[1].Big().Uint64(); height != burnLog.BlockNumber {
+		t.Fatalf("received the burn of block %d, want %d", height, burnLog.BlockNumber)
+	}
+	if logIdx := received[0].Topics[2].Big().Uint64(); logIdx != uint64(burnLog.Index) {
+		t.Fatalf("received the burn log %d, want %d", logIdx, burnLog.Index)
+	}
+
+	w3qRelayer := coordinator.GetRelayer(w3qChain.chainId).(*EthChainRelayer)
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(50 * time.Millisecond) {
+		job, err := w3qRelayer.Jobs().Get("w3q-to-eth", burnLog.TxHash, burnLog.Index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the job is finished once the mined tx succeeded
+		if job != nil && job.Stage == JobConfirmed {
+			if len(job.SubmittedTxs) != 1 || job.SubmittedTxs[0] != received[0].TxHash {
+				t.Fatalf("job submitted %v, want %s", job.SubmittedTxs, received[0].TxHash.Hex())
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job not confirmed: %+v", job)
+		}
+	}
+}
@@ -11,12 +11,13 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/ethereum/go-ethereum/ethdb/leveldb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"io/ioutil"
 	"math/big"
+	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type EthChainRelayer struct {
@@ -25,11 +26,16 @@ type EthChainRelayer struct {
 	prikey      *ecdsa.PrivateKey
 	relayerAddr common.Address
 
-	chainHeadCh     chan *types.Header
-	chainHeadSub    event.Subscription
-	latestHeaderNum *big.Int
+	chainHeadCh  chan *types.Header
+	chainHeadSub event.Subscription
+	heads        *HeadTracker
+	headerFeed   *HeaderFeed
 
-	relayerdb *leveldb.Database
+	family        ChainFamily
+	store         *ChainStore
+	quorum        *QuorumVerifier
+	contracts     ContractsConfig
+	contractsLock sync.RWMutex
 
 	recExecTaskCh    chan Task
 	recMonitorTaskCh chan IMonitorTask
@@ -40,9 +46,7 @@ type EthChainRelayer struct {
 	cancel context.CancelFunc
 }
 
-func NewEthChainRelayer(pctx context.Context, filepath string, passwd string, conf *ChainConfig) (*EthChainRelayer, error) {
-
-	ctx, cf := context.WithCancel(pctx)
+func NewEthChainRelayer(pctx context.Context, filepath string, passwd string, conf *ChainConfig, contracts ContractsConfig, storage Storage) (*EthChainRelayer, error) {
 
 	b, err := ioutil.ReadFile(filepath)
 	if err != nil {
@@ -54,18 +58,18 @@ func NewEthChainRelayer(pctx context.Context, filepath string, passwd string, co
 	}
 	relayerAddr := crypto.PubkeyToAddress(key.PrivateKey.PublicKey)
 
-	chainClient, err := NewEthChainClient(conf.httpRpc, conf.wssRpc, ctx)
+	ctx, cf := context.WithCancel(pctx)
+	chainClient, err := NewEndpointPool(ctx, conf.chainId, conf.httpRpcs, conf.wssRpcs, conf.healthCheckInterval, conf.rateLimit)
 	if err != nil {
-		return nil, err
-	}
-
-	database, err := leveldb.New(conf.leveldbDir, 16, 16, fmt.Sprintf("./relayer-%d", conf.chainId), false)
-	if err != nil {
-		return nil, err
+		cf()
+		return nil, fmt.Errorf("chain [%s]: %w", conf.name, err)
 	}
 
 	relayer := &EthChainRelayer{
-		relayerdb:        database,
+		family:           GetChainFamily(conf.family),
+		store:            NewChainStore(storage, conf.chainId, conf.headerCacheDepth),
+		heads:            NewHeadTracker(),
+		contracts:        contracts,
 		prikey:           key.PrivateKey,
 		relayerAddr:      relayerAddr,
 		ChainConfig:      conf,
@@ -76,9 +80,14 @@ func NewEthChainRelayer(pctx context.Context, filepath string, passwd string, co
 		recMonitorTaskCh: make(chan IMonitorTask),
 		recExecTaskCh:    make(chan Task),
 	}
+	if conf.quorum != nil {
+		relayer.quorum = NewQuorumVerifier(conf.chainId, conf.quorum)
+	}
+	relayer.headerFeed = NewHeaderFeed(conf.headerCacheDepth, relayer.headerByHash)
 
 	sub, receiveHeaderChan, err := relayer.SubscribeLatestHeader()
 	if err != nil {
+		relayer.Close()
 		return nil, err
 	}
 	relayer.chainHeadSub = sub
@@ -87,34 +96,267 @@ func NewEthChainRelayer(pctx context.Context, filepath string, passwd string, co
 }
 
 func (c *EthChainRelayer) wsClient() *ethclient.Client {
-	return c.chainClient.EthWsClient()
+	return c.chainClient.WsClient()
 }
 
 func (c *EthChainRelayer) httpClient() *ethclient.Client {
-	return c.chainClient.EthHttpClient()
+	return c.chainClient.HttpClient()
+}
+
+// RpcUsage returns the request counters of the endpoints of the chain, nil if the chain client keeps none
+func (c *EthChainRelayer) RpcUsage() []*EndpointUsage {
+	if pool, ok := c.chainClient.(*EndpointPool); ok {
+		return pool.Usage()
+	}
+	return nil
+}
+
+// limitedWsClient returns the ws-client once its rate limit lets calls requests of the priority through
+func (c *EthChainRelayer) limitedWsClient(priority Priority, calls int) (*ethclient.Client, error) {
+	client := c.wsClient()
+	return client, c.chainClient.Limit(c.ctx, client, priority, calls)
+}
+
+func (c *EthChainRelayer) limitedHttpClient(priority Priority, calls int) (*ethclient.Client, error) {
+	client := c.httpClient()
+	return client, c.chainClient.Limit(c.ctx, client, priority, calls)
+}
+
+// report passes the outcome of a call made with client to the chain client, which fails over an endpoint failing its
+// calls, and returns err
+func (c *EthChainRelayer) report(client *ethclient.Client, err error) error {
+	c.chainClient.Report(client, err)
+	return err
 }
 
 func (c *EthChainRelayer) SubscribeLatestHeader() (event.Subscription, chan *types.Header, error) {
 	var chainHeadCh = make(chan *types.Header)
-	sub, err := c.wsClient().SubscribeNewHead(c.ctx, chainHeadCh)
+	var sub event.Subscription
+	var err error
+	if c.ChainConfig.monitor == PollMonitor {
+		sub, err = c.pollHeads(chainHeadCh)
+	} else {
+		var client *ethclient.Client
+		if client, err = c.limitedWsClient(PriorityLive, 1); err == nil {
+			sub, err = client.SubscribeNewHead(c.ctx, chainHeadCh)
+			c.report(client, err)
+		}
+	}
 	if err != nil {
 		return nil, nil, err
 	}
 	return sub, chainHeadCh, nil
 }
 
+var (
+	ResubscribeMinBackoff = time.Second
+	ResubscribeMaxBackoff = time.Minute
+)
+
+var errRelayerStopped = errors.New("chain relayer stopped")
+
+// resubscribe calls subscribe until it succeeds. After each failure the ws connection is redialed and the delay
+// before the next attempt doubles from ResubscribeMinBackoff up to ResubscribeMaxBackoff. It gives up with
+// errMonitorTaskStopped once cancelCh fires, or with errRelayerStopped once the relayer is stopped.
+func (c *EthChainRelayer) resubscribe(cancelCh <-chan struct{}, subscribe func() error) error {
+	delay := ResubscribeMinBackoff
+	for attempt := 1; ; attempt++ {
+		stale := c.wsClient()
+		err := subscribe()
+		if err == nil {
+			if attempt > 1 {
+				log.Info("EthChainRelayer::resubscribe() succeed to resubscribe", "chainId", c.ChainId(), "attempts", attempt)
+			}
+			return nil
+		}
+		log.Warn("EthChainRelayer::resubscribe() failed to subscribe, redialing", "chainId", c.ChainId(), "attempt", attempt, "retryIn", delay, "err", err.Error())
+		if err = c.chainClient.Redial(c.ctx, stale); err != nil {
+			log.Warn("EthChainRelayer::resubscribe() failed to redial the ws-client", "chainId", c.ChainId(), "err", err.Error())
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-cancelCh:
+			return errMonitorTaskStopped
+		case <-c.ctx.Done():
+			return errRelayerStopped
+		}
+		if delay *= 2; delay > ResubscribeMaxBackoff {
+			delay = ResubscribeMaxBackoff
+		}
+	}
+}
+
+// replayHeaders inserts the headers after the cached head up to the chain head into the header store, so that the
+// blocks missed while the head subscription was down leave no gap. At most headerCacheDepth headers are replayed.
+func (c *EthChainRelayer) replayHeaders() error {
+	head, ok, err := c.store.Headers.Head()
+	if err != nil || !ok {
+		return err
+	}
+	latest, err := c.LatestBlockNumber()
+	if err != nil {
+		return err
+	}
+	from := head + 1
+	if latest >= c.store.Headers.depth && from < latest-c.store.Headers.depth+1 {
+		from = latest - c.store.Headers.depth + 1
+	}
+
+	window := uint64(c.ChainConfig.batchSize * c.ChainConfig.batchConcurrency)
+	if window == 0 {
+		window = 1
+	}
+	for start := from; start <= latest; start += window {
+		end := start + window - 1
+		if end > latest {
+			end = latest
+		}
+		headers, err := c.HeadersByRange(start, end)
+		if err != nil {
+			return err
+		}
+		for _, header := range headers {
+			if err = c.insertHead(header); err != nil {
+				return err
+			}
+		}
+	}
+	if from <= latest {
+		log.Info("EthChainRelayer::replayHeaders() replayed the headers missed by the head subscription", "chainId", c.ChainId(), "from", from, "to", latest)
+	}
+	return nil
+}
+
+func (c *EthChainRelayer) insertHead(header *types.Header) error {
+	if err := c.store.Headers.InsertHead(header, c.headerByHash); err != nil {
+		return err
+	}
+	c.heads.Update(header)
+	return c.headerFeed.Push(header)
+}
+
+// headerByHash returns the header of hash from the header store, or from the node if it is not cached
+func (c *EthChainRelayer) headerByHash(hash common.Hash) (*types.Header, error) {
+	header, err := c.store.Headers.HeaderByHash(hash)
+	if err != nil || header != nil {
+		return header, err
+	}
+	client, err := c.limitedWsClient(PriorityLive, 1)
+	if err != nil {
+		return nil, err
+	}
+	header, err = client.HeaderByHash(c.ctx, hash)
+	return header, c.report(client, err)
+}
+
 func (c *EthChainRelayer) GetSpecificHeader(number uint64) (*types.Header, error) {
-	return c.wsClient().HeaderByNumber(c.ctx, big.NewInt(0).SetUint64(number))
+	client, err := c.limitedWsClient(PriorityLive, 1)
+	if err != nil {
+		return nil, err
+	}
+	header, err := client.HeaderByNumber(c.ctx, big.NewInt(0).SetUint64(number))
+	return header, c.report(client, err)
+}
+
+// latestHeader returns the header of the head of the chain
+func (c *EthChainRelayer) latestHeader() (*types.Header, error) {
+	client, err := c.limitedWsClient(PriorityLive, 1)
+	if err != nil {
+		return nil, err
+	}
+	header, err := client.HeaderByNumber(c.ctx, nil)
+	return header, c.report(client, err)
 }
 
 func (c *EthChainRelayer) SubscribeEvent(contract common.Address, eventId common.Hash, receiveChan chan types.Log) (event.Subscription, error) {
-	sub, err := c.wsClient().SubscribeFilterLogs(c.ctx, ethereum.FilterQuery{Addresses: []common.Address{contract}, Topics: [][]common.Hash{{eventId}}}, receiveChan)
+	if c.ChainConfig.monitor == PollMonitor {
+		return c.pollLogs(contract, eventId, receiveChan)
+	}
+	client, err := c.limitedWsClient(PriorityLive, 1)
 	if err != nil {
 		return nil, err
 	}
+	sub, err := client.SubscribeFilterLogs(c.ctx, ethereum.FilterQuery{Addresses: []common.Address{contract}, Topics: [][]common.Hash{{eventId}}}, receiveChan)
+	if err = c.report(client, err); err != nil {
+		return nil, err
+	}
 	return sub, nil
 }
 
+// Heads is the tracker of the head of the chain
+func (c *EthChainRelayer) Heads() *HeadTracker {
+	return c.heads
+}
+
+// HeaderFeed is the ordered feed of the headers joining and leaving the canonical chain
+func (c *EthChainRelayer) HeaderFeed() *HeaderFeed {
+	return c.headerFeed
+}
+
+// Jobs is the store of the jobs of the routes relaying the logs of the chain
+func (c *EthChainRelayer) Jobs() *JobStore {
+	return c.store.Jobs
+}
+
+// Store is the namespace of the chain in the storage shared by the relayers
+func (c *EthChainRelayer) Store() *ChainStore {
+	return c.store
+}
+
+// Family is the chain family the relayer packs headers, proves receipts and prices gas with
+func (c *EthChainRelayer) Family() ChainFamily {
+	return c.family
+}
+
+// Quorum is the verifier of the logs observed at the chain, nil if the chain has no quorum configured
+func (c *EthChainRelayer) Quorum() *QuorumVerifier {
+	return c.quorum
+}
+
+// Checkpoints is the store of the log checkpoints of the routes relaying the logs of the chain
+func (c *EthChainRelayer) Checkpoints() *CheckpointStore {
+	return c.store.Checkpoints
+}
+
+func (c *EthChainRelayer) getContracts() ContractsConfig {
+	c.contractsLock.RLock()
+	defer c.contractsLock.RUnlock()
+	return c.contracts
+}
+
+// SetContracts replaces the contracts config, used when the config is reloaded
+func (c *EthChainRelayer) SetContracts(contracts ContractsConfig) {
+	c.contractsLock.Lock()
+	defer c.contractsLock.Unlock()
+	c.contracts = contracts
+}
+
+func (c *EthChainRelayer) LatestBlockNumber() (uint64, error) {
+	client, err := c.limitedWsClient(PriorityLive, 1)
+	if err != nil {
+		return 0, err
+	}
+	number, err := client.BlockNumber(c.ctx)
+	return number, c.report(client, err)
+}
+
+// FilterLogs returns the logs of the contract event emitted in the blocks [from, to], as a bulk read
+func (c *EthChainRelayer) FilterLogs(contract common.Address, eventId common.Hash, from, to uint64) ([]types.Log, error) {
+	query := ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(to),
+		Addresses: []common.Address{contract},
+		Topics:    [][]common.Hash{{eventId}},
+	}
+	client, err := c.limitedWsClient(PriorityBulk, 1)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := client.FilterLogs(c.ctx, query)
+	return logs, c.report(client, err)
+}
+
 func (c *EthChainRelayer) signTx(tx *types.Transaction) (*types.Transaction, error) {
 	signer := types.LatestSignerForChainID(big.NewInt(0).SetUint64(c.ChainId()))
 	signedTx, err := types.SignTx(tx, signer, c.prikey)
@@ -126,29 +368,34 @@ func (c *EthChainRelayer) signTx(tx *types.Transaction) (*types.Transaction, err
 }
 
 func (c *EthChainRelayer) suggestGasPrice() (gasTipCap *big.Int, gasFeeCap *big.Int, err error) {
-	//Estimate gasTipCap
-
-	gasTipCap, err = c.wsClient().SuggestGasTipCap(c.ctx)
+	// the families read the head and the suggested tip or gas price
+	client, err := c.limitedWsClient(PriorityTx, 2)
 	if err != nil {
 		return nil, nil, err
 	}
-
-	latestHeader, err := c.wsClient().HeaderByNumber(c.ctx, nil)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	gasFeeCap = big.NewInt(0)
-	gasFeeCap = gasFeeCap.Mul(latestHeader.BaseFee, big.NewInt(2))
-	gasFeeCap = gasFeeCap.Add(gasFeeCap, gasTipCap)
-
-	return gasTipCap, gasFeeCap, nil
+	return c.family.SuggestFees(c.ctx, client)
 }
 
+// getNonce takes the pending nonce of the node, unless the node has not seen the last tx the relayer submitted yet
 func (c *EthChainRelayer) getNonce() (uint64, error) {
 	relayerAddr := crypto.PubkeyToAddress(c.prikey.PublicKey)
-	nonce, err := c.wsClient().PendingNonceAt(c.ctx, relayerAddr)
-	return nonce, err
+	client, err := c.limitedWsClient(PriorityTx, 1)
+	if err != nil {
+		return 0, err
+	}
+	nonce, err := client.PendingNonceAt(c.ctx, relayerAddr)
+	if err != nil {
+		return 0, err
+	}
+
+	last, ok, err := c.store.Nonces.Get(relayerAddr)
+	if err != nil {
+		return 0, err
+	}
+	if ok && last+1 > nonce {
+		nonce = last + 1
+	}
+	return nonce, nil
 }
 
 func (c *EthChainRelayer) estimateGas(tx *types.DynamicFeeTx) (uint64, error) {
@@ -160,7 +407,11 @@ func (c *EthChainRelayer) estimateGas(tx *types.DynamicFeeTx) (uint64, error) {
 		Value:     tx.Value,
 		Data:      tx.Data,
 	}
-	gasLimit, err := c.wsClient().EstimateGas(c.ctx, msg)
+	client, err := c.limitedWsClient(PriorityTx, 1)
+	if err != nil {
+		return 0, err
+	}
+	gasLimit, err := client.EstimateGas(c.ctx, msg)
 	if err != nil {
 		return 0, err
 	}
@@ -169,7 +420,7 @@ func (c *EthChainRelayer) estimateGas(tx *types.DynamicFeeTx) (uint64, error) {
 }
 
 func (c *EthChainRelayer) GenTx(task *SubmitTxTask, args ...interface{}) (*types.Transaction, error) {
-	abi := GlobalContractsCfg.GetContractAbi(task.contractName)
+	abi := c.getContracts().GetContractAbi(task.contractName)
 	txdata, err := abi.Pack(task.methodName, args...)
 	if err != nil {
 		return nil, err
@@ -205,7 +456,7 @@ func (c *EthChainRelayer) GenTx(task *SubmitTxTask, args ...interface{}) (*types
 }
 
 func (c *EthChainRelayer) GenTx1(task *SubmitTxTask, args ...interface{}) (*types.Transaction, error) {
-	abi := GlobalContractsCfg.GetContractAbi(task.contractName)
+	abi := c.getContracts().GetContractAbi(task.contractName)
 	txdata, err := abi.Pack(task.methodName, args)
 	if err != nil {
 		return nil, err
@@ -240,39 +491,131 @@ func (c *EthChainRelayer) GenTx1(task *SubmitTxTask, args ...interface{}) (*type
 	return types.NewTx(tx), nil
 }
 
-func (c *EthChainRelayer) SubmitTx(tx *types.Transaction) error {
+// SubmitTx signs tx with the relayer key and broadcasts it, the signed tx is returned as its hash is the one mined
+func (c *EthChainRelayer) SubmitTx(tx *types.Transaction) (*types.Transaction, error) {
 	signedTx, err := c.signTx(tx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = c.httpClient().SendTransaction(c.ctx, signedTx)
-	return err
+	client, err := c.limitedHttpClient(PriorityTx, 1)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.report(client, client.SendTransaction(c.ctx, signedTx)); err != nil {
+		return nil, err
+	}
+
+	if err = c.store.Nonces.Put(c.relayerAddr, signedTx.Nonce()); err != nil {
+		log.Error("EthChainRelayer::SubmitTx() failed to save nonce", "chainId", c.ChainId(), "nonce", signedTx.Nonce(), "err", err.Error())
+	}
+	return signedTx, nil
+
+}
+
+// WaitMined checks at every new head whether the tx of hash is mined and returns its receipt. It returns a nil receipt
+// if the tx is dropped, being neither mined nor known to the node, and fails once ctx is done.
+func (c *EthChainRelayer) WaitMined(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	var receipt *types.Receipt
+	err := c.heads.WaitUntil(ctx, func() bool {
+		client, err := c.limitedHttpClient(PriorityLive, 2)
+		if err == nil {
+			if receipt, err = client.TransactionReceipt(ctx, hash); err == nil {
+				return true
+			}
+			if err == ethereum.NotFound {
+				if _, _, err = client.TransactionByHash(ctx, hash); err == ethereum.NotFound {
+					return true
+				}
+			}
+		}
+		if err != nil && ctx.Err() == nil {
+			log.Warn("EthChainRelayer::WaitMined() failed to get the receipt", "chainId", c.ChainId(), "txHash", hash, "err", err.Error())
+		}
+		return false
+	})
+	return receipt, err
+}
+
+// CancelTx replaces the tx of hash with an empty transfer to the relayer of the same nonce while it is pending, paying
+// 10% more gas than it so the node takes the replacement. It returns false if the tx is mined or unknown to the node.
+func (c *EthChainRelayer) CancelTx(hash common.Hash) (bool, error) {
+	client, err := c.limitedHttpClient(PriorityTx, 2)
+	if err != nil {
+		return false, err
+	}
+	tx, pending, err := client.TransactionByHash(c.ctx, hash)
+	if err == ethereum.NotFound {
+		return false, nil
+	}
+	if err != nil || !pending {
+		return false, err
+	}
 
+	bump := func(v *big.Int) *big.Int {
+		return new(big.Int).Div(new(big.Int).Mul(v, big.NewInt(110)), big.NewInt(100))
+	}
+	cancelTx, err := c.signTx(types.NewTx(&types.DynamicFeeTx{
+		Nonce:     tx.Nonce(),
+		To:        &c.relayerAddr,
+		Value:     big.NewInt(0),
+		Gas:       21000,
+		GasTipCap: bump(tx.GasTipCap()),
+		GasFeeCap: bump(tx.GasFeeCap()),
+	}))
+	if err != nil {
+		return false, err
+	}
+	if err = client.SendTransaction(c.ctx, cancelTx); err != nil {
+		return false, err
+	}
+	log.Info("EthChainRelayer::CancelTx() replaced the pending tx", "chainId", c.ChainId(), "txHash", hash, "nonce", tx.Nonce(), "cancelTx", cancelTx.Hash())
+	return true, nil
 }
 
+// IsCanonical reports whether the block of number and hash is still canonical at the chain
+func (c *EthChainRelayer) IsCanonical(number uint64, hash common.Hash) (bool, error) {
+	header, err := c.GetSpecificHeader(number)
+	if err == ethereum.NotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return header.Hash() == hash, nil
+}
+
+// GetBlockHeader returns the canonical header at number from the header store, or from rpc if it is not cached
 func (c *EthChainRelayer) GetBlockHeader(number *big.Int) (*types.Header, error) {
-	// todo : load blockHeader from db
-	//get, err := c.relayerdb.Get(number.Bytes())
-	//if get != nil {
-	//	rlp.Decode()
-	//}
+	header, err := c.store.Headers.HeaderByNumber(number.Uint64())
+	if err != nil {
+		log.Warn("EthChainRelayer::GetBlockHeader() failed to load header from header store", "chainId", c.ChainId(), "headerNum", number, "err", err.Error())
+	}
+	if header != nil {
+		return header, nil
+	}
 
-	header, err := c.GetSpecificHeader(number.Uint64())
-	return header, err
+	return c.GetSpecificHeader(number.Uint64())
 }
 
 func (c *EthChainRelayer) GetReceiptProof(txhash common.Hash) (*ethclient.ReceiptProofData, error) {
-	proof, err := c.wsClient().ReceiptProof(c.ctx, txhash)
-	return proof, err
+	client, err := c.limitedWsClient(PriorityTx, 1)
+	if err != nil {
+		return nil, err
+	}
+	return c.family.ReceiptProof(c.ctx, client, txhash)
 }
 
 func (c *EthChainRelayer) CallContract(contractName string, methodName string, args ...interface{}) ([]byte, error) {
 
-	contractInfo := GlobalContractsCfg.GetContract(contractName)
+	contractInfo := c.getContracts().GetContract(contractName)
 	if contractInfo == nil {
-		return nil, fmt.Errorf("contract [%s] ABI no exist at GlobalContractsCfg", contractName)
+		return nil, fmt.Errorf("contract [%s] ABI no exist at contracts config", contractName)
 	}
+	return c.callContract(contractInfo, methodName, args...)
+}
+
+func (c *EthChainRelayer) callContract(contractInfo *ContractDetail, methodName string, args ...interface{}) ([]byte, error) {
 	packData, err := contractInfo.Abi.Pack(methodName, args...)
 	if err != nil {
 		return nil, err
@@ -286,15 +629,20 @@ func (c *EthChainRelayer) CallContract(contractName string, methodName string, a
 		Data:  packData,
 	}
 
-	res, err := c.wsClient().CallContract(c.ctx, msg, nil)
+	client, err := c.limitedWsClient(PriorityLive, 1)
 	if err != nil {
 		return nil, err
 	}
+	res, err := client.CallContract(c.ctx, msg, nil)
+	if err = c.report(client, err); err != nil {
+		return nil, err
+	}
 	return res, nil
 }
 
-func (c *EthChainRelayer) getNextEpochHeader() (*big.Int, error) {
-	res, err := c.CallContract(LightClientContract, GetNextEpochHeightFunc)
+// NextEpochHeight asks the light client for the height of the next epoch header it expects
+func (c *EthChainRelayer) NextEpochHeight(lightClient *ContractDetail, methodName string) (*big.Int, error) {
+	res, err := c.callContract(lightClient, methodName)
 	if err != nil {
 		return nil, err
 	}
@@ -303,19 +651,31 @@ func (c *EthChainRelayer) getNextEpochHeader() (*big.Int, error) {
 	return height, nil
 }
 
-func (c *EthChainRelayer) IsW3qHeaderExistAtLightClient(web3qHeadrNumber *big.Int) (bool, error) {
-
-	res, err := c.CallContract(LightClientContract, BlockExistFunc, web3qHeadrNumber)
+// IsHeaderExist asks the light client whether the header of the given number has been submitted
+func (c *EthChainRelayer) IsHeaderExist(lightClient *ContractDetail, methodName string, number *big.Int) (bool, error) {
+	res, err := c.callContract(lightClient, methodName, number)
 	if err != nil {
 		return false, err
 	}
 
 	exist := big.NewInt(0).SetBytes(res)
-	if exist.Uint64() == 0 {
-		return false, nil
-	} else {
-		return true, nil
+	return exist.Sign() != 0, nil
+}
+
+func (c *EthChainRelayer) getNextEpochHeader() (*big.Int, error) {
+	lightClient := c.getContracts().GetContract(LightClientContract)
+	if lightClient == nil {
+		return nil, fmt.Errorf("contract [%s] ABI no exist at contracts config", LightClientContract)
+	}
+	return c.NextEpochHeight(lightClient, GetNextEpochHeightFunc)
+}
+
+func (c *EthChainRelayer) IsW3qHeaderExistAtLightClient(web3qHeadrNumber *big.Int) (bool, error) {
+	lightClient := c.getContracts().GetContract(LightClientContract)
+	if lightClient == nil {
+		return false, fmt.Errorf("contract [%s] ABI no exist at contracts config", LightClientContract)
 	}
+	return c.IsHeaderExist(lightClient, BlockExistFunc, web3qHeadrNumber)
 }
 
 func (c *EthChainRelayer) checkTaskValidity(task IMonitorTask) error {
@@ -363,6 +723,16 @@ func (c *EthChainRelayer) running() error {
 	if atomic.LoadUint32(&c.status) != ChainRelayerDoing {
 		return fmt.Errorf("EthChainRelayer::Running() with invalid status [%d]", atomic.LoadUint32(&c.status))
 	}
+
+	// the head subscription only delivers the heads after the current one, the latest header seeds the heads unless
+	// some are tracked already
+	if c.heads.Latest() == nil {
+		if header, err := c.latestHeader(); err != nil {
+			log.Warn("EthChainRelayer::Running() failed to get the latest header", "chainId", c.ChainId(), "err", err.Error())
+		} else if err = c.insertHead(header); err != nil {
+			log.Error("EthChainRelayer::Running() failed to put header into header store", "chainId", c.ChainId(), "headerNum", header.Number.Uint64(), "err", err.Error())
+		}
+	}
 	for {
 		select {
 		case task := <-c.recMonitorTaskCh:
@@ -380,42 +750,34 @@ func (c *EthChainRelayer) running() error {
 			// todo : It seems that move the task.StartMonitor() to taskManager is a better way
 			go task.StartMonitor()
 
-		//case header := <-c.chainHeadCh:
-		//	// store latest 100 header at stateDB
-		//	log.Info("EthChainRelayer::running() get header from subscription", "chainId", c.ChainId(), "headerNum", header.Number.Uint64())
-		//	buffer := new(bytes.Buffer)
-		//	err := rlp.Encode(buffer, header)
-		//	if err != nil {
-		//		log.Error("EthChainRelayer::running() failed to rlp-encoding header", "chainId", c.ChainId(), "headerNum", header.Number.Uint64(), "err", err.Error())
-		//		// todo : take a better dealing mechanism for this case
-		//		continue
-		//	}
-		//
-		//	// todo : it seems that should consider the case fork choice happened
-		//	//get, err := c.relayerdb.Get(header.Number.Bytes())
-		//	//if get != nil {
-		//	//	continue
-		//	//}
-		//
-		//	err = c.relayerdb.Put(header.Number.Bytes(), buffer.Bytes())
-		//	if err != nil {
-		//		log.Error("ChainRelayer::Running() failed to put header into relayerDb", "chainId", c.ChainId(), "headerNum", header.Number.Uint64(), "err", err.Error())
-		//		// todo : take a better dealing mechanism for this case
-		//		continue
-		//	}
-		//	c.latestHeaderNum = header.Number
-		//
-		//case herr := <-c.chainHeadSub.Err():
-		//	log.Error("EthChainRelayer::Running() the latest header subscription happened error", "chainId", c.ChainId(), "err", herr.Error())
-		//	c.chainHeadSub.Unsubscribe()
-		//
-		//	sub, receiveHeaderChan, err := c.SubscribeLatestHeader()
-		//	if err != nil {
-		//		log.Error("EthChainRelayer::Running() failed to resubscribe the latestHeader , prepare to quit the EthChainRelayer ", "chainId", c.ChainId(), "err", herr.Error())
-		//		c.Stop()
-		//	}
-		//	c.chainHeadSub = sub
-		//	c.chainHeadCh = receiveHeaderChan
+		case header := <-c.chainHeadCh:
+			log.Debug("EthChainRelayer::running() get header from subscription", "chainId", c.ChainId(), "headerNum", header.Number.Uint64())
+			if err := c.insertHead(header); err != nil {
+				log.Error("EthChainRelayer::running() failed to put header into header store", "chainId", c.ChainId(), "headerNum", header.Number.Uint64(), "err", err.Error())
+			}
+
+		case herr := <-c.chainHeadSub.Err():
+			log.Error("EthChainRelayer::Running() the latest header subscription happened error", "chainId", c.ChainId(), "err", herr)
+			c.chainHeadSub.Unsubscribe()
+
+			// the relayer takes no task until the head subscription is back
+			err := c.resubscribe(nil, func() error {
+				sub, receiveHeaderChan, err := c.SubscribeLatestHeader()
+				if err != nil {
+					return err
+				}
+				c.chainHeadSub = sub
+				c.chainHeadCh = receiveHeaderChan
+				return nil
+			})
+			if err != nil {
+				log.Info("EthChainRelayer::Running() EthChainRelayer receive stop-signal while resubscribing", "chainId", c.ChainId())
+				atomic.StoreUint32(&c.status, ChainRelayerStopped)
+				return nil
+			}
+			if err = c.replayHeaders(); err != nil {
+				log.Error("EthChainRelayer::Running() failed to replay the missed headers", "chainId", c.ChainId(), "err", err.Error())
+			}
 
 		case <-c.ctx.Done():
 			log.Info("EthChainRelayer::Running() EthChainRelayer receive stop-signal and will be done ", "chainId", c.ChainId())
@@ -430,8 +792,19 @@ func (c *EthChainRelayer) Stop() error {
 		return fmt.Errorf("EthChainRelayer::Stop() with invalid status [%d]", atomic.LoadUint32(&c.status))
 	}
 
-	GlobalCoordinator.taskManager.StopTaskBySpecificChainId(c.ChainId())
+	c.cancel()
+	return nil
+}
 
+// Close releases the rpc clients of the relayer, it should be called after Stop
+func (c *EthChainRelayer) Close() error {
 	c.cancel()
+	if c.chainHeadSub != nil {
+		c.chainHeadSub.Unsubscribe()
+	}
+	c.chainClient.Close()
+	if c.quorum != nil {
+		c.quorum.Close()
+	}
 	return nil
 }
@@ -1,20 +1,44 @@
 package v2
 
 import (
+	"context"
+	"errors"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 	"log"
 	"math/big"
+	"net"
+	"os"
 	"testing"
+	"time"
 )
 
+// liveCoordinator builds a coordinator against the live chains of the config file named by RELAYER_TEST_CONFIG
+func liveCoordinator(t *testing.T) (*Coordinator, *ChainConfig, *ChainConfig) {
+	path := os.Getenv("RELAYER_TEST_CONFIG")
+	if path == "" {
+		t.Skip("RELAYER_TEST_CONFIG is not set, skip the test against live chains")
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	coordinator, err := NewCoordinatorFromConfig(cfg, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(coordinator.Stop)
+
+	return coordinator, cfg.Chain(Web3qChainName), cfg.Chain(EthereumChainName)
+}
+
 func TestEthChainRelayer_IsW3qHeaderExistAtLightClient(t *testing.T) {
-	//ethRelayer, err := NewEthChainRelayer(context.Background(), "/Users/chenyanlong/Work/go-ethereum/cmd/geth/data_val_0/keystore/UTC--2022-06-07T04-15-42.696295000Z--96f22a48dcd4dfb99a11560b24bee02f374ca77d", "123", EthereumChainConf)
-	//if err != nil {
-	//	panic(err)
-	//}
+	coordinator, w3qConf, ethConf := liveCoordinator(t)
 
-	w3qRelayer := GlobalCoordinator.relayers[5].(*EthChainRelayer)
-	ethRelayer := GlobalCoordinator.relayers[5].(*EthChainRelayer)
+	w3qRelayer := coordinator.relayers[w3qConf.chainId].(*EthChainRelayer)
+	ethRelayer := coordinator.relayers[ethConf.chainId].(*EthChainRelayer)
 	// todo : check big.Int or uint64 is valid
 	// both wsClient and http client validity
 	res, err := ethRelayer.IsW3qHeaderExistAtLightClient(big.NewInt(0))
@@ -28,7 +52,17 @@ func TestEthChainRelayer_IsW3qHeaderExistAtLightClient(t *testing.T) {
 	}
 
 	header, err := w3qRelayer.GetSpecificHeader(height.Uint64())
-	submitTask := GlobalCoordinator.taskManager.GenSubmitWeb3qHeader_SubmitTxTask_OnEth()
+	route, err := NewRoute(&RouteConfig{
+		Name:        "w3q-to-eth",
+		Source:      RouteSource{Contract: Web3qBridgeContract, Event: ETHEventSendTokenName},
+		HeaderRelay: &RouteHeaderRelay{Contract: LightClientContract},
+		Target:      RouteTarget{Contract: EthereumBridgeContract, Method: receiveFromWeb3qFunc},
+		Args:        []string{"log.blockNumber", "receiptProof", "log.index"},
+	}, coordinator.contracts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	submitTask := coordinator.taskManager.GenSubmitHeader_SubmitTxTask(route)
 
 	tx, err := submitTask.submitTxFunc(w3qRelayer, ethRelayer, header, submitTask)
 	if err != nil {
@@ -45,17 +79,129 @@ func TestEthChainRelayer_IsW3qHeaderExistAtLightClient(t *testing.T) {
 }
 
 func TestEthChainRelayer_GenTx(t *testing.T) {
+	coordinator, w3qConf, ethConf := liveCoordinator(t)
 
-	submitTask := GlobalCoordinator.taskManager.GenReceiveToken_SubmitTxTask_OnWeb3q()
-	w3qRelayer := GlobalCoordinator.relayers[3333].(*EthChainRelayer)
+	submitTask := coordinator.taskManager.GenReceiveToken_SubmitTxTask_OnWeb3q(ethConf, w3qConf)
+	w3qRelayer := coordinator.relayers[w3qConf.chainId].(*EthChainRelayer)
 	tx, err := w3qRelayer.GenTx(submitTask, common.HexToHash("0x9f031fef0bc3f78fd28a0d37d61e16ffd72487a10b82703c2ceb825116b3dc0a"), big.NewInt(0))
 	if err != nil {
 		t.Error(err)
 	}
 
-	err = w3qRelayer.SubmitTx(tx)
+	_, err = w3qRelayer.SubmitTx(tx)
 	if err != nil {
 		t.Error("txHash:", tx.Hash(), "  err:", err)
 	}
 
 }
+
+// redialCounter is a chain client without connections that counts the redials
+type redialCounter struct {
+	redials int
+}
+
+func (r *redialCounter) ChainId() uint64               { return 5 }
+func (r *redialCounter) WsClient() *ethclient.Client   { return nil }
+func (r *redialCounter) HttpClient() *ethclient.Client { return nil }
+func (r *redialCounter) Close()                        {}
+
+func (r *redialCounter) Redial(ctx context.Context, stale *ethclient.Client) error {
+	r.redials++
+	return nil
+}
+
+func (r *redialCounter) Limit(ctx context.Context, client *ethclient.Client, priority Priority, calls int) error {
+	return nil
+}
+
+func (r *redialCounter) RpcClient(client *ethclient.Client) *rpc.Client {
+	return nil
+}
+
+func (r *redialCounter) Report(client *ethclient.Client, err error) {}
+
+func TestEthChainRelayerResubscribe(t *testing.T) {
+	minBackoff, maxBackoff := ResubscribeMinBackoff, ResubscribeMaxBackoff
+	ResubscribeMinBackoff, ResubscribeMaxBackoff = time.Millisecond, 4*time.Millisecond
+	defer func() { ResubscribeMinBackoff, ResubscribeMaxBackoff = minBackoff, maxBackoff }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := new(redialCounter)
+	relayer := &EthChainRelayer{ChainConfig: &ChainConfig{chainId: 5}, chainClient: client, ctx: ctx, cancel: cancel}
+
+	attempts := 0
+	err := relayer.resubscribe(nil, func() error {
+		if attempts++; attempts < 4 {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	if err != nil || attempts != 4 || client.redials != 3 {
+		t.Fatalf("resubscribe: err %v attempts %d redials %d", err, attempts, client.redials)
+	}
+
+	cancelCh := make(chan struct{})
+	go func() { cancelCh <- struct{}{} }()
+	err = relayer.resubscribe(cancelCh, func() error { return errors.New("connection refused") })
+	if err != errMonitorTaskStopped {
+		t.Fatalf("resubscribe of a stopped task: err %v", err)
+	}
+
+	cancel()
+	err = relayer.resubscribe(nil, func() error { return errors.New("connection refused") })
+	if err != errRelayerStopped {
+		t.Fatalf("resubscribe of a stopped relayer: err %v", err)
+	}
+}
+
+func TestEthChainClientRedialTimeout(t *testing.T) {
+	timeout := RedialTimeout
+	RedialTimeout = 100 * time.Millisecond
+	defer func() { RedialTimeout = timeout }()
+
+	// the endpoint accepts the connection but never answers the ws handshake
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	stale := ethclient.NewClient(rpc.DialInProc(rpc.NewServer()))
+	client := &EthChainClient{chainId: 5, wsUrl: "ws://" + ln.Addr().String(), wsClient: stale}
+	done := make(chan error)
+	go func() {
+		done <- client.Redial(context.Background(), stale)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	got := make(chan *ethclient.Client)
+	go func() {
+		got <- client.WsClient()
+	}()
+	select {
+	case c := <-got:
+		if c != stale {
+			t.Fatal("ws-client replaced by a failing redial")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("WsClient blocked by the redial")
+	}
+	select {
+	case err = <-done:
+		if err == nil {
+			t.Fatal("redialed an endpoint that never answers")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("redial not bounded by RedialTimeout")
+	}
+}
@@ -0,0 +1,119 @@
+package v2
+
+import (
+	"context"
+	"fmt"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// ConfirmationsFinality takes a block as final once confirmations blocks are built on top of it
+	ConfirmationsFinality = "confirmations"
+	// FinalizedFinality and SafeFinality take the blocks up to the finalized or safe block tag of the node as final
+	FinalizedFinality = "finalized"
+	SafeFinality      = "safe"
+	// InstantFinality takes a block as final once it is mined, for Tendermint-style chains like Web3q
+	InstantFinality = "instant"
+
+	DefaultConfirmations = 2
+)
+
+// FinalityConfig is the policy deciding when a block of a chain is final, the jobs of the logs emitted at the chain
+// wait for their block to be final before their headers and txs are submitted
+type FinalityConfig struct {
+	Policy        string `json:"policy"`
+	Confirmations uint64 `json:"confirmations,omitempty"` // of ConfirmationsFinality, defaults to 2
+}
+
+// defaultFinality is instant for the web3q family and 2 confirmations otherwise
+func defaultFinality(family string) *FinalityConfig {
+	if family == Web3qFamily {
+		return &FinalityConfig{Policy: InstantFinality}
+	}
+	return &FinalityConfig{Policy: ConfirmationsFinality, Confirmations: DefaultConfirmations}
+}
+
+func (c *FinalityConfig) validate() error {
+	switch c.Policy {
+	case ConfirmationsFinality, FinalizedFinality, SafeFinality, InstantFinality:
+		return nil
+	}
+	return fmt.Errorf("unknown finality policy [%s]", c.Policy)
+}
+
+// FinalizedBlock returns the number of the latest block that is final by the finality policy of the chain
+func (c *EthChainRelayer) FinalizedBlock() (uint64, error) {
+	policy := c.ChainConfig.Finality()
+	switch policy.Policy {
+	case FinalizedFinality, SafeFinality:
+		return c.taggedBlock(policy.Policy)
+	}
+
+	latest, err := c.LatestBlockNumber()
+	if err != nil {
+		return 0, err
+	}
+	if policy.Policy == ConfirmationsFinality {
+		if latest < policy.Confirmations {
+			return 0, nil
+		}
+		return latest - policy.Confirmations, nil
+	}
+	return latest, nil
+}
+
+// taggedBlock returns the number of the block of the finalized or safe tag, which not every node serves
+func (c *EthChainRelayer) taggedBlock(tag string) (uint64, error) {
+	client, err := c.limitedWsClient(PriorityLive, 1)
+	if err != nil {
+		return 0, err
+	}
+	rpcClient := c.chainClient.RpcClient(client)
+	if rpcClient == nil {
+		return 0, errNoRpcClient
+	}
+
+	var header *types.Header
+	if err = c.report(client, rpcClient.CallContext(c.ctx, &header, "eth_getBlockByNumber", tag, false)); err != nil {
+		return 0, err
+	}
+	if header == nil {
+		return 0, fmt.Errorf("node serves no %s block", tag)
+	}
+	return header.Number.Uint64(), nil
+}
+
+// WaitFinalized blocks until the block of number is final by the finality policy of the chain, following the heads
+// of the chain. It fails once ctx is done or the relayer is stopped.
+func (c *EthChainRelayer) WaitFinalized(pctx context.Context, number uint64) error {
+	ctx, cancel := context.WithCancel(pctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-c.ctx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	policy := c.ChainConfig.Finality()
+	switch policy.Policy {
+	case InstantFinality:
+		_, err := c.heads.WaitForHeight(ctx, number)
+		return err
+	case ConfirmationsFinality:
+		_, err := c.heads.WaitForHeight(ctx, number+policy.Confirmations)
+		return err
+	}
+
+	// the block tags move with the heads
+	return c.heads.WaitUntil(ctx, func() bool {
+		final, err := c.taggedBlock(policy.Policy)
+		if err != nil {
+			log.Warn("EthChainRelayer::WaitFinalized() failed to get the finalized block", "chainId", c.ChainId(), "policy", policy.Policy, "err", err.Error())
+			return false
+		}
+		return final >= number
+	})
+}
@@ -0,0 +1,56 @@
+package v2
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFinalizedBlock(t *testing.T) {
+	f := newFakeEth(5)
+	for i := 0; i < 4; i++ {
+		f.mine()
+	}
+	relayer := newPollingRelayer(t, f)
+
+	policies := map[string]uint64{ConfirmationsFinality: 2, InstantFinality: 4}
+	for policy, want := range policies {
+		relayer.ChainConfig.finality = &FinalityConfig{Policy: policy, Confirmations: 2}
+		if final, err := relayer.FinalizedBlock(); err != nil || final != want {
+			t.Errorf("%s: finalized block %d err %v, want %d", policy, final, err, want)
+		}
+	}
+	// the fake node serves no finalized tag
+	relayer.ChainConfig.finality = &FinalityConfig{Policy: FinalizedFinality}
+	if _, err := relayer.FinalizedBlock(); err == nil {
+		t.Error("finalized tag served by a node without it")
+	}
+
+	// the wait follows the heads of the relayer
+	relayer.ChainConfig.finality = &FinalityConfig{Policy: ConfirmationsFinality, Confirmations: 2}
+	relayer.Heads().Update(f.headers[4])
+	done := make(chan error)
+	go func() {
+		done <- relayer.WaitFinalized(context.Background(), 3)
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("block 3 final with head 4: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	relayer.Heads().Update(f.mine())
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("block 3 not final with head 5")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := relayer.WaitFinalized(ctx, 100); err != context.Canceled {
+		t.Fatalf("wait of a stopped job returned %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package v2
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/core/types"
+	"sync"
+)
+
+// HeadTracker follows the head of a chain, fed by the head subscription of its EthChainRelayer. Tasks read the latest
+// head or wait for a height from it instead of polling the node.
+type HeadTracker struct {
+	lock   sync.Mutex
+	latest *types.Header
+	// updated is closed and replaced on every new head
+	updated chan struct{}
+}
+
+func NewHeadTracker() *HeadTracker {
+	return &HeadTracker{updated: make(chan struct{})}
+}
+
+// Update makes header the latest head, a reorg to a shorter chain may lower the height
+func (t *HeadTracker) Update(header *types.Header) {
+	t.lock.Lock()
+	t.latest = header
+	close(t.updated)
+	t.updated = make(chan struct{})
+	t.lock.Unlock()
+}
+
+// Latest returns the latest head, or nil if no head has been tracked yet
+func (t *HeadTracker) Latest() *types.Header {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.latest
+}
+
+// WaitForHeight blocks until the latest head is at height n or above and returns it. It fails once ctx is done.
+func (t *HeadTracker) WaitForHeight(ctx context.Context, n uint64) (*types.Header, error) {
+	for {
+		t.lock.Lock()
+		latest, updated := t.latest, t.updated
+		t.lock.Unlock()
+		if latest != nil && latest.Number.Uint64() >= n {
+			return latest, nil
+		}
+
+		select {
+		case <-updated:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// WaitUntil calls cond at once and again at every new head until it holds, for the states of the chain that can only
+// change with a new block. It fails once ctx is done.
+func (t *HeadTracker) WaitUntil(ctx context.Context, cond func() bool) error {
+	for {
+		latest := t.Latest()
+		if cond() {
+			return nil
+		}
+
+		var next uint64
+		if latest != nil {
+			next = latest.Number.Uint64() + 1
+		}
+		if _, err := t.WaitForHeight(ctx, next); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package v2
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/core/types"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHeadTracker(t *testing.T) {
+	tracker := NewHeadTracker()
+	if tracker.Latest() != nil {
+		t.Fatal("head tracked before any update")
+	}
+	headers := makeHeaders(&types.Header{Number: big.NewInt(-1), Difficulty: big.NewInt(1)}, 4, 0)
+
+	waited := make(chan *types.Header)
+	go func() {
+		header, err := tracker.WaitForHeight(context.Background(), 2)
+		if err != nil {
+			t.Error(err)
+		}
+		waited <- header
+	}()
+
+	for _, header := range headers[:2] {
+		tracker.Update(header)
+	}
+	select {
+	case header := <-waited:
+		t.Fatalf("height 2 reached at head %d", header.Number)
+	case <-time.After(20 * time.Millisecond):
+	}
+	tracker.Update(headers[2])
+	select {
+	case header := <-waited:
+		if header.Number.Uint64() != 2 {
+			t.Fatalf("waited for head %d, want 2", header.Number)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("height 2 not reached")
+	}
+	if latest := tracker.Latest(); latest.Hash() != headers[2].Hash() {
+		t.Fatalf("latest head %d, want 2", latest.Number)
+	}
+
+	// the condition is checked again at the next head only
+	var ready atomic.Bool
+	until := make(chan error)
+	go func() {
+		until <- tracker.WaitUntil(context.Background(), ready.Load)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	ready.Store(true)
+	select {
+	case err := <-until:
+		t.Fatalf("condition checked without a new head: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	tracker.Update(headers[3])
+	select {
+	case err := <-until:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("condition not checked at the new head")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := tracker.WaitForHeight(ctx, 10); err != context.Canceled {
+		t.Fatalf("wait with a done context returned %v", err)
+	}
+}
@@ -0,0 +1,202 @@
+package v2
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"sync"
+)
+
+type HeaderEventType string
+
+const (
+	HeaderCanonical HeaderEventType = "canonical" // the header joins the canonical chain
+	HeaderReverted  HeaderEventType = "reverted"  // the header leaves the canonical chain by a reorg
+	// HeaderReset restarts the stream from the header: the headers between the last event and it are skipped, and the
+	// headers sent before may have left the canonical chain without a reverted event
+	HeaderReset HeaderEventType = "reset"
+)
+
+// HeaderEvent is sent by a HeaderFeed for every header that joins or leaves the canonical chain
+type HeaderEvent struct {
+	Type   HeaderEventType
+	Header *types.Header
+}
+
+// HeaderFeed turns the heads of a chain, which may skip numbers or jump back on reorgs, into an ordered stream of
+// header events without gaps. Every head is linked by parent hash to the headers sent before: the missing headers of
+// a gap are fetched, and on a reorg the headers of the old branch are reverted from the top down before the new branch
+// is sent from the common ancestor up. The last depth canonical headers are kept to find the common ancestor; a gap or
+// reorg deeper than them resets the stream to the head, as does a subscriber falling 4 * depth events behind.
+type HeaderFeed struct {
+	lock      sync.Mutex
+	depth     uint64
+	headers   []*types.Header // the canonical headers sent, by ascending number
+	getHeader func(hash common.Hash) (*types.Header, error)
+	subs      map[*headerQueue]struct{}
+}
+
+func NewHeaderFeed(depth uint64, getHeader func(hash common.Hash) (*types.Header, error)) *HeaderFeed {
+	if depth == 0 {
+		depth = DefaultHeaderCacheDepth
+	}
+	return &HeaderFeed{depth: depth, getHeader: getHeader, subs: make(map[*headerQueue]struct{})}
+}
+
+// headerQueue holds the events of a subscriber not delivered yet, so a slow subscriber never blocks Push
+type headerQueue struct {
+	lock   sync.Mutex
+	events []*HeaderEvent
+	limit  int
+	wake   chan struct{}
+}
+
+// push queues the events up to head, a queue growing beyond its limit is replaced by a reset to head
+func (q *headerQueue) push(events []*HeaderEvent, head *types.Header) {
+	q.lock.Lock()
+	if len(q.events)+len(events) > q.limit {
+		q.events = []*HeaderEvent{{Type: HeaderReset, Header: head}}
+	} else {
+		q.events = append(q.events, events...)
+	}
+	q.lock.Unlock()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *headerQueue) pop() *HeaderEvent {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if len(q.events) == 0 {
+		return nil
+	}
+	ev := q.events[0]
+	q.events[0] = nil
+	q.events = q.events[1:]
+	return ev
+}
+
+// Subscribe sends the header events to ch. Every subscriber has its own queue and goroutine delivering from it, so
+// a slow subscriber neither blocks the head subscription of the relayer nor the other subscribers.
+func (f *HeaderFeed) Subscribe(ch chan<- *HeaderEvent) event.Subscription {
+	q := &headerQueue{limit: int(4 * f.depth), wake: make(chan struct{}, 1)}
+	f.lock.Lock()
+	f.subs[q] = struct{}{}
+	f.lock.Unlock()
+
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer func() {
+			f.lock.Lock()
+			delete(f.subs, q)
+			f.lock.Unlock()
+		}()
+		for {
+			ev := q.pop()
+			if ev == nil {
+				select {
+				case <-q.wake:
+					continue
+				case <-quit:
+					return nil
+				}
+			}
+			select {
+			case ch <- ev:
+			case <-quit:
+				return nil
+			}
+		}
+	})
+}
+
+// canonical returns the canonical header sent at number, or nil if it is not kept
+func (f *HeaderFeed) canonical(number uint64) *types.Header {
+	if len(f.headers) == 0 {
+		return nil
+	}
+	first := f.headers[0].Number.Uint64()
+	if number < first || number-first >= uint64(len(f.headers)) {
+		return nil
+	}
+	return f.headers[number-first]
+}
+
+// Push queues the events making head the canonical head for the subscribers. A head that is canonical already, or
+// older than the kept headers, is dropped. If a missing header cannot be fetched no event is queued, the gap is filled
+// again from the next head. At most depth headers are fetched, a head that does not link to the kept headers within
+// them resets the feed.
+func (f *HeaderFeed) Push(head *types.Header) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if known := f.canonical(head.Number.Uint64()); known != nil && known.Hash() == head.Hash() {
+		return nil
+	}
+	if len(f.headers) > 0 && head.Number.Uint64() < f.headers[0].Number.Uint64() {
+		return nil
+	}
+	if len(f.headers) > 0 && head.Number.Uint64() > f.headers[len(f.headers)-1].Number.Uint64()+f.depth {
+		f.reset(head)
+		return nil
+	}
+
+	// walk back from the head to the kept header it builds on, a branch forking below the kept headers replaces them all
+	branch := []*types.Header{head}
+	cur := head
+	for len(f.headers) > 0 {
+		number := cur.Number.Uint64()
+		if number == 0 || number <= f.headers[0].Number.Uint64() {
+			break
+		}
+		if parent := f.canonical(number - 1); parent != nil && parent.Hash() == cur.ParentHash {
+			break
+		}
+		if uint64(len(branch)) >= f.depth {
+			f.reset(head)
+			return nil
+		}
+		parent, err := f.getHeader(cur.ParentHash)
+		if err != nil {
+			return fmt.Errorf("failed to get the header %s: %w", cur.ParentHash.Hex(), err)
+		}
+		if parent == nil || parent.Number.Uint64()+1 != number {
+			return fmt.Errorf("header %s is no parent of header %d", cur.ParentHash.Hex(), number)
+		}
+		branch = append(branch, parent)
+		cur = parent
+	}
+
+	from := cur.Number.Uint64()
+	var events []*HeaderEvent
+	for i := len(f.headers) - 1; i >= 0 && f.headers[i].Number.Uint64() >= from; i-- {
+		events = append(events, &HeaderEvent{Type: HeaderReverted, Header: f.headers[i]})
+		f.headers = f.headers[:i]
+	}
+	for i := len(branch) - 1; i >= 0; i-- {
+		f.headers = append(f.headers, branch[i])
+		events = append(events, &HeaderEvent{Type: HeaderCanonical, Header: branch[i]})
+	}
+	if n := uint64(len(f.headers)); n > f.depth {
+		f.headers = f.headers[n-f.depth:]
+	}
+	f.send(events)
+	return nil
+}
+
+// reset drops the kept headers and restarts the stream from head
+func (f *HeaderFeed) reset(head *types.Header) {
+	f.headers = []*types.Header{head}
+	f.send([]*HeaderEvent{{Type: HeaderReset, Header: head}})
+}
+
+// send queues the events for the subscribers, under the lock so every subscriber gets the events of concurrent pushes
+// in order
+func (f *HeaderFeed) send(events []*HeaderEvent) {
+	head := f.headers[len(f.headers)-1]
+	for q := range f.subs {
+		q.push(events, head)
+	}
+}
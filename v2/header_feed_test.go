@@ -0,0 +1,196 @@
+package v2
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestHeaderFeed(t *testing.T) {
+	known := make(map[common.Hash]*types.Header)
+	getHeader := func(hash common.Hash) (*types.Header, error) {
+		if header, ok := known[hash]; ok {
+			return header, nil
+		}
+		return nil, fmt.Errorf("unknown header %s", hash.Hex())
+	}
+	feed := NewHeaderFeed(8, getHeader)
+	events := make(chan *HeaderEvent, 64)
+	sub := feed.Subscribe(events)
+	defer sub.Unsubscribe()
+
+	// expect checks that the events sent are the reverted headers followed by the canonical headers
+	expect := func(reverted []*types.Header, canonical ...*types.Header) {
+		t.Helper()
+		want := make([]*HeaderEvent, 0)
+		for _, header := range reverted {
+			want = append(want, &HeaderEvent{Type: HeaderReverted, Header: header})
+		}
+		for _, header := range canonical {
+			want = append(want, &HeaderEvent{Type: HeaderCanonical, Header: header})
+		}
+		for _, w := range want {
+			select {
+			case ev := <-events:
+				if ev.Type != w.Type || ev.Header.Hash() != w.Header.Hash() {
+					t.Fatalf("got %s header %d, want %s header %d", ev.Type, ev.Header.Number, w.Type, w.Header.Number)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("no %s event for header %d", w.Type, w.Header.Number)
+			}
+		}
+		select {
+		case ev := <-events:
+			t.Fatalf("unexpected %s event for header %d", ev.Type, ev.Header.Number)
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+
+	expectReset := func(head *types.Header) {
+		t.Helper()
+		select {
+		case ev := <-events:
+			if ev.Type != HeaderReset || ev.Header.Hash() != head.Hash() {
+				t.Fatalf("got %s header %d, want a reset to header %d", ev.Type, ev.Header.Number, head.Number)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no reset to header %d", head.Number)
+		}
+		expect(nil)
+	}
+
+	// a subscriber that never reads blocks neither Push nor the other subscribers
+	stalled := feed.Subscribe(make(chan *HeaderEvent))
+	defer stalled.Unsubscribe()
+
+	genesis := &types.Header{Number: big.NewInt(0), Difficulty: big.NewInt(1)}
+	chainA := makeHeaders(genesis, 6, 'a')
+	for _, header := range chainA {
+		known[header.Hash()] = header
+	}
+	if err := feed.Push(chainA[0]); err != nil {
+		t.Fatal(err)
+	}
+	expect(nil, chainA[0])
+
+	// the skipped headers are fetched and sent in order, a head sent already is dropped
+	if err := feed.Push(chainA[3]); err != nil {
+		t.Fatal(err)
+	}
+	expect(nil, chainA[1:4]...)
+	if err := feed.Push(chainA[2]); err != nil {
+		t.Fatal(err)
+	}
+	expect(nil)
+
+	// a head that cannot be linked sends nothing, the gap is filled from the next head
+	chainB := makeHeaders(chainA[1], 4, 'b')
+	if err := feed.Push(chainB[3]); err == nil {
+		t.Fatal("pushed a head without its ancestors")
+	}
+	expect(nil)
+
+	// chain b forks after block 2, the headers of chain a are reverted from the top down
+	for _, header := range chainB {
+		known[header.Hash()] = header
+	}
+	if err := feed.Push(chainB[3]); err != nil {
+		t.Fatal(err)
+	}
+	expect([]*types.Header{chainA[3], chainA[2]}, chainB...)
+
+	// a shorter chain jumping back below the head reverts the headers above it
+	chainC := makeHeaders(chainB[0], 1, 'c')
+	for _, header := range chainC {
+		known[header.Hash()] = header
+	}
+	if err := feed.Push(chainC[0]); err != nil {
+		t.Fatal(err)
+	}
+	expect([]*types.Header{chainB[3], chainB[2], chainB[1]}, chainC[0])
+
+	// a fork deeper than the kept headers resets the feed to the head
+	feed = NewHeaderFeed(2, getHeader)
+	sub.Unsubscribe()
+	sub = feed.Subscribe(events)
+	for _, header := range chainA[:4] {
+		if err := feed.Push(header); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expect(nil, chainA[:4]...)
+
+	// a head older than the kept headers is dropped instead of replacing them
+	if err := feed.Push(chainA[0]); err != nil {
+		t.Fatal(err)
+	}
+	expect(nil)
+	if err := feed.Push(chainB[3]); err != nil {
+		t.Fatal(err)
+	}
+	expectReset(chainB[3])
+
+	// a gap wider than the kept headers resets the feed without fetching it
+	fetched := 0
+	feed = NewHeaderFeed(2, func(hash common.Hash) (*types.Header, error) {
+		fetched++
+		return getHeader(hash)
+	})
+	sub.Unsubscribe()
+	sub = feed.Subscribe(events)
+	if err := feed.Push(chainA[0]); err != nil {
+		t.Fatal(err)
+	}
+	expect(nil, chainA[0])
+	if err := feed.Push(chainA[4]); err != nil {
+		t.Fatal(err)
+	}
+	expectReset(chainA[4])
+	if err := feed.Push(chainA[5]); err != nil {
+		t.Fatal(err)
+	}
+	expect(nil, chainA[5])
+	if fetched != 0 {
+		t.Fatalf("%d headers fetched for a gap wider than the kept headers", fetched)
+	}
+}
+
+func TestHeaderFeedQueueLimit(t *testing.T) {
+	genesis := &types.Header{Number: big.NewInt(0), Difficulty: big.NewInt(1)}
+	headers := makeHeaders(genesis, 20, 0)
+	feed := NewHeaderFeed(2, nil)
+	events := make(chan *HeaderEvent)
+	sub := feed.Subscribe(events)
+	defer sub.Unsubscribe()
+
+	// the subscriber falls more than 4 * depth events behind, its queue is replaced by a reset to the head and the
+	// later heads follow it. The event taken off the queue before is delivered first.
+	for _, header := range headers {
+		if err := feed.Push(header); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var reset *types.Header
+	for i := 0; ; i++ {
+		select {
+		case ev := <-events:
+			switch {
+			case ev.Type == HeaderReset && reset == nil:
+				reset = ev.Header
+			case ev.Type == HeaderCanonical && reset == nil && i == 0:
+			case ev.Type == HeaderCanonical && reset != nil && ev.Header.Number.Uint64() == reset.Number.Uint64()+1:
+				reset = ev.Header
+			default:
+				t.Fatalf("unexpected %s event for header %d", ev.Type, ev.Header.Number)
+			}
+			if reset != nil && reset.Hash() == headers[19].Hash() {
+				return
+			}
+		case <-time.After(time.Second):
+			t.Fatal("no reset to the head")
+		}
+	}
+}
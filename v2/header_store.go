@@ -0,0 +1,253 @@
+package v2
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"sync"
+)
+
+// DefaultHeaderCacheDepth is the number of recent headers kept if the chain config sets no headerCacheDepth
+const DefaultHeaderCacheDepth = 256
+
+var (
+	headerHeadKey      = []byte("header/head")
+	headerCanonicalPfx = []byte("header/n/") // header/n/<number> -> canonical hash
+	headerPfx          = []byte("header/h/") // header/h/<number><hash> -> header
+	headerNumberPfx    = []byte("header/i/") // header/i/<hash> -> number
+)
+
+func encodeNumber(number uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, number)
+	return b
+}
+
+func headerCanonicalKey(number uint64) []byte {
+	return append(append([]byte{}, headerCanonicalPfx...), encodeNumber(number)...)
+}
+
+func headerKey(number uint64, hash common.Hash) []byte {
+	return append(append(append([]byte{}, headerPfx...), encodeNumber(number)...), hash.Bytes()...)
+}
+
+func headerNumberKey(hash common.Hash) []byte {
+	return append(append([]byte{}, headerNumberPfx...), hash.Bytes()...)
+}
+
+// storedHeader keeps the commit apart from the header like PackedWeb3qHeader does, as the commit is no rlp field
+type storedHeader struct {
+	Header []byte
+	Commit []byte
+}
+
+func encodeHeader(header *types.Header) ([]byte, error) {
+	stored := new(storedHeader)
+	var err error
+	if header.Commit != nil {
+		stored.Header, stored.Commit, err = PackedWeb3qHeader(header)
+	} else {
+		stored.Header, err = rlp.EncodeToBytes(header)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(stored)
+}
+
+func decodeHeader(b []byte) (*types.Header, error) {
+	stored := new(storedHeader)
+	if err := rlp.DecodeBytes(b, stored); err != nil {
+		return nil, err
+	}
+	header := new(types.Header)
+	if err := rlp.DecodeBytes(stored.Header, header); err != nil {
+		return nil, err
+	}
+	if len(stored.Commit) != 0 {
+		header.Commit = new(types.Commit)
+		if err := rlp.DecodeBytes(stored.Commit, header.Commit); err != nil {
+			return nil, err
+		}
+	}
+	return header, nil
+}
+
+// HeaderStore caches the recent headers of a chain by number and hash. The canonical mapping from number to hash
+// follows the head subscription and is rewritten when a reorg is seen, headers deeper than depth are pruned.
+type HeaderStore struct {
+	db    Storage
+	depth uint64
+	lock  sync.Mutex
+}
+
+func NewHeaderStore(db Storage, depth uint64) *HeaderStore {
+	if depth == 0 {
+		depth = DefaultHeaderCacheDepth
+	}
+	return &HeaderStore{db: db, depth: depth}
+}
+
+// Head returns the number of the latest header inserted by InsertHead, ok is false if none
+func (s *HeaderStore) Head() (number uint64, ok bool, err error) {
+	has, err := s.db.Has(headerHeadKey)
+	if err != nil || !has {
+		return 0, false, err
+	}
+	b, err := s.db.Get(headerHeadKey)
+	if err != nil {
+		return 0, false, err
+	}
+	return binary.BigEndian.Uint64(b), true, nil
+}
+
+// CanonicalHash returns the hash of the canonical header at number, or the empty hash if it is not cached
+func (s *HeaderStore) CanonicalHash(number uint64) (common.Hash, error) {
+	key := headerCanonicalKey(number)
+	has, err := s.db.Has(key)
+	if err != nil || !has {
+		return common.Hash{}, err
+	}
+	b, err := s.db.Get(key)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(b), nil
+}
+
+// HeaderByHash returns the cached header of the hash, canonical or not, or nil
+func (s *HeaderStore) HeaderByHash(hash common.Hash) (*types.Header, error) {
+	key := headerNumberKey(hash)
+	has, err := s.db.Has(key)
+	if err != nil || !has {
+		return nil, err
+	}
+	b, err := s.db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err = s.db.Get(headerKey(binary.BigEndian.Uint64(b), hash))
+	if err != nil {
+		return nil, err
+	}
+	return decodeHeader(b)
+}
+
+// HeaderByNumber returns the cached canonical header at number, or nil
+func (s *HeaderStore) HeaderByNumber(number uint64) (*types.Header, error) {
+	hash, err := s.CanonicalHash(number)
+	if err != nil || hash == (common.Hash{}) {
+		return nil, err
+	}
+	return s.HeaderByHash(hash)
+}
+
+func (s *HeaderStore) writeHeader(batch ethdb.Batch, header *types.Header) error {
+	b, err := encodeHeader(header)
+	if err != nil {
+		return err
+	}
+	number := header.Number.Uint64()
+	if err = batch.Put(headerKey(number, header.Hash()), b); err != nil {
+		return err
+	}
+	return batch.Put(headerNumberKey(header.Hash()), encodeNumber(number))
+}
+
+// InsertHead makes header the canonical head. If its ancestors are not the cached canonical headers, the canonical
+// mapping is rewritten back to the common ancestor (at most depth headers deep), fetching the missing ancestors with
+// getHeader, and the canonical headers above the new head are dropped.
+func (s *HeaderStore) InsertHead(header *types.Header, getHeader func(hash common.Hash) (*types.Header, error)) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	number := header.Number.Uint64()
+	oldHead, hasHead, err := s.Head()
+	if err != nil {
+		return err
+	}
+
+	batch := s.db.NewBatch()
+	if err = s.writeHeader(batch, header); err != nil {
+		return err
+	}
+	if err = batch.Put(headerCanonicalKey(number), header.Hash().Bytes()); err != nil {
+		return err
+	}
+
+	cur := header
+	for i := uint64(0); i < s.depth && cur.Number.Uint64() > 0; i++ {
+		parentNumber := cur.Number.Uint64() - 1
+		canonical, err := s.CanonicalHash(parentNumber)
+		if err != nil {
+			return err
+		}
+		if canonical == (common.Hash{}) || canonical == cur.ParentHash {
+			// no cached header beyond, or the common ancestor is reached
+			break
+		}
+
+		parent, err := s.HeaderByHash(cur.ParentHash)
+		if err != nil {
+			return err
+		}
+		if parent == nil {
+			if parent, err = getHeader(cur.ParentHash); err != nil {
+				return fmt.Errorf("failed to get the reorged header %s: %w", cur.ParentHash.Hex(), err)
+			}
+			if err = s.writeHeader(batch, parent); err != nil {
+				return err
+			}
+		}
+		if err = batch.Put(headerCanonicalKey(parentNumber), parent.Hash().Bytes()); err != nil {
+			return err
+		}
+		cur = parent
+	}
+
+	if hasHead {
+		for n := number + 1; n <= oldHead; n++ {
+			if err = batch.Delete(headerCanonicalKey(n)); err != nil {
+				return err
+			}
+		}
+	}
+	if err = batch.Put(headerHeadKey, encodeNumber(number)); err != nil {
+		return err
+	}
+
+	if number >= s.depth {
+		if err = s.prune(batch, number-s.depth); err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
+
+// prune drops the headers below number together with their canonical mapping
+func (s *HeaderStore) prune(batch ethdb.Batch, number uint64) error {
+	it := s.db.NewIterator(headerPfx, nil)
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()[len(headerPfx):]
+		n := binary.BigEndian.Uint64(key[:8])
+		if n >= number {
+			break
+		}
+		if err := batch.Delete(it.Key()); err != nil {
+			return err
+		}
+		if err := batch.Delete(headerNumberKey(common.BytesToHash(key[8:]))); err != nil {
+			return err
+		}
+		if err := batch.Delete(headerCanonicalKey(n)); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
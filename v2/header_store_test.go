@@ -0,0 +1,83 @@
+package v2
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"math/big"
+	"testing"
+)
+
+// makeHeaders builds n headers on top of parent, fork tells the branches apart
+func makeHeaders(parent *types.Header, n int, fork byte) []*types.Header {
+	headers := make([]*types.Header, 0, n)
+	for i := 0; i < n; i++ {
+		header := &types.Header{Number: new(big.Int).Add(parent.Number, big.NewInt(1)), ParentHash: parent.Hash(), Extra: []byte{fork}, Difficulty: big.NewInt(1)}
+		headers = append(headers, header)
+		parent = header
+	}
+	return headers
+}
+
+func TestHeaderStoreReorg(t *testing.T) {
+	store := NewHeaderStore(NewMemoryStorage(), 8)
+	known := make(map[common.Hash]*types.Header)
+	getHeader := func(hash common.Hash) (*types.Header, error) {
+		if header, ok := known[hash]; ok {
+			return header, nil
+		}
+		return nil, fmt.Errorf("unknown header %s", hash.Hex())
+	}
+
+	genesis := &types.Header{Number: big.NewInt(0), Difficulty: big.NewInt(1)}
+	chainA := makeHeaders(genesis, 6, 'a')
+	for _, header := range append([]*types.Header{genesis}, chainA...) {
+		if err := store.InsertHead(header, getHeader); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// chain b forks after block 3 and only its head is seen by the subscription
+	chainB := makeHeaders(chainA[2], 2, 'b')
+	for _, header := range chainB {
+		known[header.Hash()] = header
+	}
+	if err := store.InsertHead(chainB[1], getHeader); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := append(append([]*types.Header{genesis}, chainA[:3]...), chainB...)
+	for _, header := range expect {
+		got, err := store.HeaderByNumber(header.Number.Uint64())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got == nil || got.Hash() != header.Hash() {
+			t.Fatalf("unexpected canonical header at %d", header.Number.Uint64())
+		}
+	}
+	if got, _ := store.HeaderByNumber(6); got != nil {
+		t.Fatalf("the reorged block 6 is still canonical")
+	}
+	if got, _ := store.HeaderByHash(chainA[5].Hash()); got == nil {
+		t.Fatalf("the reorged header is dropped before it is pruned")
+	}
+
+	// headers deeper than the depth are pruned
+	chainC := makeHeaders(chainB[1], 10, 'c')
+	for _, header := range chainC {
+		if err := store.InsertHead(header, getHeader); err != nil {
+			t.Fatal(err)
+		}
+	}
+	head := chainC[len(chainC)-1].Number.Uint64()
+	for n := uint64(0); n <= head; n++ {
+		got, err := store.HeaderByNumber(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (got != nil) != (n >= head-8) {
+			t.Fatalf("header %d cached %v with head %d and depth 8", n, got != nil, head)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package v2
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"sort"
+	"sync"
+	"time"
+)
+
+type JobStage string
+
+const (
+	JobObserved  JobStage = "observed"  // the event log is received from the source chain
+	JobVerified  JobStage = "verified"  // the log is confirmed by the quorum of the source chain
+	JobScheduled JobStage = "scheduled" // the source header is relayed, the log is sent to the submit-tx task
+	JobSubmitted JobStage = "submitted" // the tx calling the target method is sent
+	JobConfirmed JobStage = "confirmed" // the tx calling the target method is mined and succeeded
+	JobReverted  JobStage = "reverted"  // the log is removed from the source chain by a reorg
+)
+
+// Job is the processing record of an event log observed by a route
+type Job struct {
+	Route        string        `json:"route"`
+	ChainId      uint64        `json:"chainId"`
+	TxHash       common.Hash   `json:"txHash"`
+	LogIndex     uint          `json:"logIndex"`
+	Stage        JobStage      `json:"stage"`
+	Log          *types.Log    `json:"log"`
+	SubmittedTxs []common.Hash `json:"submittedTxs,omitempty"`
+	LastError    string        `json:"lastError,omitempty"`
+	// Disagreements are the quorum providers that returned another log than the observed one
+	Disagreements []*QuorumDisagreement `json:"disagreements,omitempty"`
+	CreatedAt     int64                 `json:"createdAt"`
+	UpdatedAt     int64                 `json:"updatedAt"`
+}
+
+// Finished reports whether the job needs no more processing, a reverted job is processed again if its log reappears
+func (job *Job) Finished() bool {
+	return job.Stage == JobConfirmed || job.Stage == JobReverted
+}
+
+var jobPrefix = []byte("job/")
+
+// jobKey is job/<route>/<chainId><txHash><logIndex>, route names carry no '/'
+func jobKey(route string, chainId uint64, txHash common.Hash, logIndex uint) []byte {
+	key := append(jobRoutePrefix(route), make([]byte, 8)...)
+	binary.BigEndian.PutUint64(key[len(key)-8:], chainId)
+	key = append(key, txHash.Bytes()...)
+	key = append(key, make([]byte, 4)...)
+	binary.BigEndian.PutUint32(key[len(key)-4:], uint32(logIndex))
+	return key
+}
+
+func jobRoutePrefix(route string) []byte {
+	key := append([]byte{}, jobPrefix...)
+	key = append(key, route...)
+	return append(key, '/')
+}
+
+// errJobReverted is returned for the updates of a job that was reverted or observed again in another block since
+var errJobReverted = errors.New("job reverted by a reorg")
+
+// JobStore persists the jobs of the routes relaying the logs of a chain. The read-modify-writes of the jobs are
+// serialized by lock, as the monitor reverts jobs while the verify, schedule and submit tasks advance them.
+type JobStore struct {
+	lock    sync.Mutex
+	chainId uint64
+	db      Storage
+}
+
+func NewJobStore(chainId uint64, db Storage) *JobStore {
+	return &JobStore{chainId: chainId, db: db}
+}
+
+// Get returns the job of the log, or nil if the log has not been observed
+func (s *JobStore) Get(route string, txHash common.Hash, logIndex uint) (*Job, error) {
+	key := jobKey(route, s.chainId, txHash, logIndex)
+	has, err := s.db.Has(key)
+	if err != nil || !has {
+		return nil, err
+	}
+
+	b, err := s.db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	job := new(Job)
+	if err = json.Unmarshal(b, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (s *JobStore) Put(job *Job) error {
+	return s.put(s.db, job)
+}
+
+func (s *JobStore) put(w ethdb.KeyValueWriter, job *Job) error {
+	job.UpdatedAt = time.Now().Unix()
+	if job.CreatedAt == 0 {
+		job.CreatedAt = job.UpdatedAt
+	}
+	b, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return w.Put(jobKey(job.Route, job.ChainId, job.TxHash, job.LogIndex), b)
+}
+
+// Observe records the log as a new job of the route, it returns false if the log has been observed before. A log
+// that was reverted, or that reappears in another block, starts its job over from JobObserved.
+func (s *JobStore) Observe(route string, l *types.Log) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.observe(s.db, route, l)
+}
+
+// observe records the log as a new job into w, the caller holds the lock
+
+func (s *JobStore) observe(w ethdb.KeyValueWriter, route string, l *types.Log) (bool, error) {
+	job, err := s.Get(route, l.TxHash, l.Index)
+	if err != nil {
+		return false, err
+	}
+	if job == nil {
+		job = &Job{Route: route, ChainId: s.chainId, TxHash: l.TxHash, LogIndex: l.Index}
+	} else if job.Stage != JobReverted && job.Log.BlockHash == l.BlockHash {
+		return false, nil
+	}
+
+	// the submitted txs and disagreements of a reappeared log are kept as its history
+	job.Stage, job.Log, job.LastError = JobObserved, l, ""
+	return true, s.put(w, job)
+}
+
+// Revert moves the job of the log removed by a reorg to JobReverted, it returns the job or nil if the log has not
+// been observed. Logs of another block than the one of the job are ignored, as the job was observed again since.
+func (s *JobStore) Revert(route string, l *types.Log) (*Job, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	job, err := s.Get(route, l.TxHash, l.Index)
+	if err != nil || job == nil || job.Log.BlockHash != l.BlockHash {
+		return nil, err
+	}
+	if job.Stage != JobReverted {
+		job.Stage = JobReverted
+		job.LastError = fmt.Sprintf("removed by a reorg of block %d (%s)", l.BlockNumber, l.BlockHash.Hex())
+		if err = s.Put(job); err != nil {
+			return nil, err
+		}
+	}
+	return job, nil
+}
+
+// Update moves the job of the log to stage, recording the submitted tx if txHash is not empty. It fails with
+// errJobReverted if the job was reverted or observed again in another block.
+func (s *JobStore) Update(route string, l *types.Log, stage JobStage, txHash common.Hash) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	job, err := s.Get(route, l.TxHash, l.Index)
+	if err != nil {
+		return err
+	}
+	if job == nil {
+		job = &Job{Route: route, ChainId: s.chainId, TxHash: l.TxHash, LogIndex: l.Index, Log: l}
+	} else if job.Stage == JobReverted || job.Log.BlockHash != l.BlockHash {
+		return errJobReverted
+	}
+
+	job.Stage = stage
+	if txHash != (common.Hash{}) {
+		job.SubmittedTxs = append(job.SubmittedTxs, txHash)
+	}
+	job.LastError = ""
+	return s.Put(job)
+}
+
+// RecordQuorum records the disagreements of the quorum verification of the log, moving its job to JobVerified if the
+// log is verified and failing it otherwise. It fails with errJobReverted like Update.
+func (s *JobStore) RecordQuorum(route string, l *types.Log, res *QuorumResult) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	job, err := s.Get(route, l.TxHash, l.Index)
+	if err != nil {
+		return err
+	}
+	if job == nil {
+		job = &Job{Route: route, ChainId: s.chainId, TxHash: l.TxHash, LogIndex: l.Index, Stage: JobObserved, Log: l}
+	} else if job.Stage == JobReverted || job.Log.BlockHash != l.BlockHash {
+		return errJobReverted
+	}
+
+	job.Disagreements = append(job.Disagreements, res.Disagreements...)
+	if res.Verified() {
+		job.Stage, job.LastError = JobVerified, ""
+	} else {
+		job.LastError = fmt.Sprintf("quorum not reached: %d of %d confirmations", res.Confirmations, res.Threshold)
+	}
+	return s.Put(job)
+}
+
+// Fail records the error that the job of the log failed with at its current stage
+func (s *JobStore) Fail(route string, l *types.Log, jobErr error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	job, err := s.Get(route, l.TxHash, l.Index)
+	if err != nil {
+		return err
+	}
+	if job == nil {
+		job = &Job{Route: route, ChainId: s.chainId, TxHash: l.TxHash, LogIndex: l.Index, Stage: JobObserved, Log: l}
+	}
+	job.LastError = jobErr.Error()
+	return s.Put(job)
+}
+
+// Unfinished returns the jobs of the route that are not finished, in the order they were emitted
+func (s *JobStore) Unfinished(route string) ([]*Job, error) {
+	it := s.db.NewIterator(jobRoutePrefix(route), nil)
+	defer it.Release()
+
+	jobs := make([]*Job, 0)
+	for it.Next() {
+		job := new(Job)
+		if err := json.Unmarshal(it.Value(), job); err != nil {
+			return nil, err
+		}
+		if !job.Finished() {
+			jobs = append(jobs, job)
+		}
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].Log.BlockNumber != jobs[j].Log.BlockNumber {
+			return jobs[i].Log.BlockNumber < jobs[j].Log.BlockNumber
+		}
+		return jobs[i].Log.Index < jobs[j].Log.Index
+	})
+	return jobs, nil
+}
@@ -0,0 +1,119 @@
+package v2
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"testing"
+)
+
+func TestJobStore(t *testing.T) {
+	db := NewMemoryStorage()
+	store := NewJobStore(3333, db)
+
+	logs := []*types.Log{
+		{TxHash: common.HexToHash("0x02"), Index: 1, BlockNumber: 20, Topics: []common.Hash{}, Data: []byte{}},
+		{TxHash: common.HexToHash("0x01"), Index: 3, BlockNumber: 10, Topics: []common.Hash{}, Data: []byte{}},
+		{TxHash: common.HexToHash("0x03"), Index: 0, BlockNumber: 20, Topics: []common.Hash{}, Data: []byte{}},
+	}
+	for _, l := range logs {
+		isNew, err := store.Observe("w3q-to-eth", l)
+		if err != nil || !isNew {
+			t.Fatalf("observe log %s: new %v err %v", l.TxHash.Hex(), isNew, err)
+		}
+	}
+	if isNew, err := store.Observe("w3q-to-eth", logs[0]); err != nil || isNew {
+		t.Fatalf("observe the same log twice: new %v err %v", isNew, err)
+	}
+	if isNew, err := store.Observe("other", logs[0]); err != nil || !isNew {
+		t.Fatalf("observe the log by another route: new %v err %v", isNew, err)
+	}
+
+	if err := store.Update("w3q-to-eth", logs[1], JobScheduled, common.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Update("w3q-to-eth", logs[0], JobSubmitted, common.HexToHash("0xaa")); err != nil {
+		t.Fatal(err)
+	}
+
+	// a restarted relayer sees the same jobs
+	store = NewJobStore(3333, db)
+	job, err := store.Get("w3q-to-eth", logs[0].TxHash, logs[0].Index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Stage != JobSubmitted || len(job.SubmittedTxs) != 1 || job.SubmittedTxs[0] != common.HexToHash("0xaa") {
+		t.Fatalf("unexpected submitted job %+v", job)
+	}
+
+	// a submitted job is finished once its tx succeeded
+	if err = store.Update("w3q-to-eth", logs[0], JobConfirmed, common.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	jobs, err := store.Unfinished("w3q-to-eth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 2 || jobs[0].TxHash != logs[1].TxHash || jobs[0].Stage != JobScheduled || jobs[1].TxHash != logs[2].TxHash {
+		t.Fatalf("unexpected unfinished jobs %+v", jobs)
+	}
+}
+
+func TestJobStoreRevert(t *testing.T) {
+	store := NewJobStore(3333, NewMemoryStorage())
+
+	l := &types.Log{TxHash: common.HexToHash("0x01"), Index: 2, BlockNumber: 10, BlockHash: common.HexToHash("0xb1"), Topics: []common.Hash{}, Data: []byte{}}
+	if _, err := store.Observe("w3q-to-eth", l); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Update("w3q-to-eth", l, JobSubmitted, common.HexToHash("0xaa")); err != nil {
+		t.Fatal(err)
+	}
+
+	// the removal of the log from another block is ignored
+	other := *l
+	other.BlockHash = common.HexToHash("0xb2")
+	if job, err := store.Revert("w3q-to-eth", &other); err != nil || job != nil {
+		t.Fatalf("revert the log of another block: job %+v err %v", job, err)
+	}
+	job, err := store.Revert("w3q-to-eth", l)
+	if err != nil || job == nil || job.Stage != JobReverted || len(job.SubmittedTxs) != 1 {
+		t.Fatalf("unexpected reverted job %+v err %v", job, err)
+	}
+	if jobs, err := store.Unfinished("w3q-to-eth"); err != nil || len(jobs) != 0 {
+		t.Fatalf("reverted job is unfinished: %+v err %v", jobs, err)
+	}
+
+	// the tasks still holding the log do not advance the reverted job
+	if err = store.Update("w3q-to-eth", l, JobScheduled, common.Hash{}); err != errJobReverted {
+		t.Fatalf("update the reverted job: err %v", err)
+	}
+	if err = store.RecordQuorum("w3q-to-eth", l, &QuorumResult{Confirmations: 1, Threshold: 1}); err != errJobReverted {
+		t.Fatalf("record the quorum of the reverted job: err %v", err)
+	}
+	if job, err = store.Get("w3q-to-eth", l.TxHash, l.Index); err != nil || job.Stage != JobReverted {
+		t.Fatalf("reverted job advanced: %+v err %v", job, err)
+	}
+
+	// the log reappears in a new block and starts over
+	other.BlockNumber = 11
+	if isNew, err := store.Observe("w3q-to-eth", &other); err != nil || !isNew {
+		t.Fatalf("observe the reappeared log: new %v err %v", isNew, err)
+	}
+	job, err = store.Get("w3q-to-eth", l.TxHash, l.Index)
+	if err != nil || job.Stage != JobObserved || job.Log.BlockHash != other.BlockHash || len(job.SubmittedTxs) != 1 {
+		t.Fatalf("unexpected reappeared job %+v err %v", job, err)
+	}
+
+	// a log moved to another block without its removal being seen starts over as well
+	moved := other
+	moved.BlockHash = common.HexToHash("0xb3")
+	if isNew, err := store.Observe("w3q-to-eth", &moved); err != nil || !isNew {
+		t.Fatalf("observe the moved log: new %v err %v", isNew, err)
+	}
+	if isNew, err := store.Observe("w3q-to-eth", &moved); err != nil || isNew {
+		t.Fatalf("observe the moved log twice: new %v err %v", isNew, err)
+	}
+	if err = store.Update("w3q-to-eth", &other, JobVerified, common.Hash{}); err != errJobReverted {
+		t.Fatalf("update the job of the former block: err %v", err)
+	}
+}
@@ -2,41 +2,43 @@ package v2
 
 import (
 	"fmt"
-	"github.com/ethereum/go-ethereum"
-	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"sync"
 	"sync/atomic"
 )
 
+// MonitorHeaderTask sends on the events of the header feed of a chain relayer, which follows the head subscription
+// of the relayer across its resubscriptions
 type MonitorHeaderTask struct {
 	targetChainId uint64
 
-	sendDataCh chan *types.Header
+	sendDataCh chan *HeaderEvent
+	relayer    *EthChainRelayer
 
 	MonitorFunc func(c IChainRelayer) (err error)
-	recCh       chan *types.Header
-	sub         ethereum.Subscription
+	recCh       chan *HeaderEvent
+	sub         event.Subscription
 	errCh       chan error
 
 	cancelCh chan struct{}
 	status   uint32 // 0 means that the execution has not started yet, 1 means that it is executing, and 2 means that the execution is over.
 
-	pwg sync.WaitGroup
+	pwg *sync.WaitGroup
 }
 
-func NewMonitorHeaderTask(pwg sync.WaitGroup, targetChainId uint64) *MonitorHeaderTask {
+func NewMonitorHeaderTask(pwg *sync.WaitGroup, targetChainId uint64) *MonitorHeaderTask {
 	return &MonitorHeaderTask{
 		targetChainId: targetChainId,
 		status:        0,
 		errCh:         make(chan error),
-		recCh:         make(chan *types.Header),
+		recCh:         make(chan *HeaderEvent, 16),
 		cancelCh:      make(chan struct{}),
 		pwg:           pwg,
 	}
 }
 
-func (task *MonitorHeaderTask) SubscribeData(sendDataCh chan *types.Header) error {
+func (task *MonitorHeaderTask) SubscribeData(sendDataCh chan *HeaderEvent) error {
 	if task.sendDataCh != nil {
 		return fmt.Errorf("%s MonitorHeaderTask has been subscribed", task.Name())
 	}
@@ -95,27 +97,42 @@ func (task *MonitorHeaderTask) Monitoring() {
 				// Todo : to confirm whether task.stop() has high probability of producing a panic when the task.sub is nil
 				task.Stop()
 			}
-		case data := <-task.recCh:
-			log.Debug("MonitorHeaderTask::Monitoring() receive header", "chainId", task.targetChainId, "headerNum", data.Number.Uint64())
-			if task.sendDataCh != nil {
-				log.Debug("MonitorHeaderTask::Monitoring() sending header to next processing program", "chainId", task.targetChainId, "headerNum", data.Number.Uint64())
-				task.sendDataCh <- data
+		case ev := <-task.recCh:
+			log.Debug("MonitorHeaderTask::Monitoring() receive header", "chainId", task.targetChainId, "headerNum", ev.Header.Number.Uint64(), "event", ev.Type)
+			if err := task.sendHeader(ev); err != nil {
+				return
 			}
-		case err := <-task.sub.Err():
-			task.errCh <- err
 
 		case <-task.cancelCh:
-			log.Info("MonitorHeaderTask::Monitoring() receive the stop-signal", "chainId", task.TargetChainId())
-			task.sub.Unsubscribe()
-			//close(task.errCh)
-			//close(task.recCh)
-			//close(task.cancelCh)
-			task.SetStatus(MonitorTaskStopped)
+			task.stopped()
 			return
 		}
 	}
 }
 
+// stopped is called by the monitoring goroutine once it receives the stop-signal
+func (task *MonitorHeaderTask) stopped() {
+	log.Info("MonitorHeaderTask::Monitoring() receive the stop-signal", "chainId", task.TargetChainId())
+	task.sub.Unsubscribe()
+	//close(task.errCh)
+	//close(task.recCh)
+	//close(task.cancelCh)
+	task.SetStatus(MonitorTaskStopped)
+}
+
+func (task *MonitorHeaderTask) sendHeader(ev *HeaderEvent) error {
+	if task.sendDataCh != nil {
+		log.Debug("MonitorHeaderTask::Monitoring() sending header to next processing program", "chainId", task.targetChainId, "headerNum", ev.Header.Number.Uint64(), "event", ev.Type)
+		select {
+		case task.sendDataCh <- ev:
+		case <-task.cancelCh:
+			task.stopped()
+			return errMonitorTaskStopped
+		}
+	}
+	return nil
+}
+
 func (task *MonitorHeaderTask) Stop() error {
 	log.Info("MonitorHeaderTask::Stop() send the stop-signal to the monitor-contract-event task ", "chainId", task.TargetChainId())
 	if atomic.LoadUint32(&task.status) == MonitorTaskMonitoring {
@@ -136,14 +153,15 @@ func (task *MonitorHeaderTask) Status() uint32 {
 }
 
 func (manager *TaskManager) GenSubHeaderMonitorTask(targetChainId uint64) *MonitorHeaderTask {
-	task := NewMonitorHeaderTask(manager.wg, targetChainId)
+	task := NewMonitorHeaderTask(&manager.wg, targetChainId)
 	ef := func(c IChainRelayer) (err error) {
 		r := c.(*EthChainRelayer)
 		if targetChainId != r.ChainId() {
 			return fmt.Errorf("task chainId %d no match with relayer chainId %d", targetChainId, r.ChainId())
 		}
-		task.sub, task.recCh, err = c.(*EthChainRelayer).SubscribeLatestHeader()
-		return err
+		task.relayer = r
+		task.sub = r.HeaderFeed().Subscribe(task.recCh)
+		return nil
 	}
 	task.MonitorFunc = ef
 
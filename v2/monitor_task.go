@@ -1,6 +1,7 @@
 package v2
 
 import (
+	"errors"
 	"fmt"
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
@@ -8,6 +9,7 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 const (
@@ -35,6 +37,17 @@ type MonitorTask struct {
 	eventId       common.Hash
 
 	sendDataCh chan interface{}
+	// filter drops the logs it returns false for before they are sent on
+	filter func(l *types.Log) bool
+	// revert handles the logs removed from the canonical chain by a reorg, they are never sent on
+	revert func(l *types.Log)
+
+	// route names the checkpoint of the task, the logs from the checkpoint (or from startBlock if none is saved yet)
+	// to the head are backfilled before the logs of the subscription are sent on. The subscription logs backfilled
+	// already are dropped by the filter, unless a reorg moved them to another block.
+	route      string
+	startBlock uint64
+	relayer    *EthChainRelayer
 
 	MonitorFunc func(c IChainRelayer) (err error)
 	recCh       chan types.Log
@@ -44,10 +57,10 @@ type MonitorTask struct {
 	cancelCh chan struct{}
 	status   uint32 // 0 means that the execution has not started yet, 1 means that it is executing, and 2 means that the execution is over.
 
-	pwg sync.WaitGroup
+	pwg *sync.WaitGroup
 }
 
-func NewMonitorEventTask(pwg sync.WaitGroup, targetChainId uint64, contractAddr common.Address, eventName string, eventId common.Hash) *MonitorTask {
+func NewMonitorEventTask(pwg *sync.WaitGroup, targetChainId uint64, contractAddr common.Address, eventName string, eventId common.Hash) *MonitorTask {
 	return &MonitorTask{
 		targetChainId: targetChainId,
 		contractAddr:  contractAddr,
@@ -111,6 +124,21 @@ func (task *MonitorTask) monitoring() {
 
 	log.Info("MonitorTask::Monitoring() running the monitor-contract-event task", "chainId", task.TargetChainId(), "contract", task.contractAddr, "event", task.eventName, "eventId", task.eventId.Hex())
 
+	buffered, err := task.bufferedBackfill()
+	if err != nil {
+		if err != errMonitorTaskStopped {
+			log.Error("MonitorTask::Monitoring() failed to backfill logs, stop the task", "chainId", task.TargetChainId(), "contract", task.contractAddr, "event", task.eventName, "err", err.Error())
+			task.sub.Unsubscribe()
+			task.SetStatus(MonitorTaskStopped)
+		}
+		return
+	}
+	for i := range buffered {
+		if err = task.handleLog(&buffered[i]); err != nil {
+			return
+		}
+	}
+
 	for {
 		select {
 		case err := <-task.errCh:
@@ -120,26 +148,205 @@ func (task *MonitorTask) monitoring() {
 				task.Stop()
 			}
 		case data := <-task.recCh:
-			log.Info("MonitorTask::Monitoring() receive event log", "chainId", task.targetChainId, "event", task.eventName, "Address", data.Topics[0].Hex(), "topics", data.Topics[1:])
-			if task.sendDataCh != nil {
-				log.Info("MonitorTask::Monitoring() sending data to next processing program", "chainId", task.targetChainId, "event", task.eventName, "Address", data.Topics[0].Hex(), "topics", data.Topics[1:])
-				task.sendDataCh <- &data
+			if err := task.handleLog(&data); err != nil {
+				return
 			}
 		case err := <-task.sub.Err():
-			task.errCh <- err
+			if err = task.resubscribe(err); err != nil {
+				return
+			}
 
 		case <-task.cancelCh:
-			log.Info("MonitorTask::Monitoring() receive the stop-signal", "chainId", task.TargetChainId(), "contract", task.contractAddr, "event", task.eventName)
-			task.sub.Unsubscribe()
-			//close(task.errCh)
-			//close(task.recCh)
-			//close(task.cancelCh)
-			task.SetStatus(MonitorTaskStopped)
+			task.stopped()
 			return
 		}
 	}
 }
 
+// handleLog sends on a log of the subscription, or reverts it if a reorg removed it
+func (task *MonitorTask) handleLog(data *types.Log) error {
+	if data.Removed {
+		log.Warn("MonitorTask::Monitoring() receive event log removed by a reorg", "chainId", task.targetChainId, "event", task.eventName, "txHash", data.TxHash, "logIndex", data.Index, "block", data.BlockNumber, "blockHash", data.BlockHash)
+		if task.revert != nil {
+			task.revert(data)
+		}
+		return nil
+	}
+	log.Info("MonitorTask::Monitoring() receive event log", "chainId", task.targetChainId, "event", task.eventName, "Address", data.Topics[0].Hex(), "topics", data.Topics[1:])
+	return task.sendLog(data)
+}
+
+// bufferedBackfill runs the backfill while the logs of the subscription are drained into a buffer, so that the
+// subscription does not overflow and drop during a long backfill. It returns the buffered logs to be handled next.
+func (task *MonitorTask) bufferedBackfill() ([]types.Log, error) {
+	var buffered []types.Log
+	done, drained := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(drained)
+		for {
+			select {
+			case l := <-task.recCh:
+				buffered = append(buffered, l)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err := task.backfill()
+	close(done)
+	<-drained
+	return buffered, err
+}
+
+// resubscribe re-establishes the dropped log subscription and replays the logs emitted during the outage from the
+// checkpoint. It only fails once the task or the relayer is stopped, leaving the task stopped.
+func (task *MonitorTask) resubscribe(subErr error) error {
+	log.Warn("MonitorTask::resubscribe() the log subscription dropped, resubscribing", "chainId", task.targetChainId, "contract", task.contractAddr, "event", task.eventName, "err", subErr)
+	for {
+		task.sub.Unsubscribe()
+		err := task.relayer.resubscribe(task.cancelCh, func() (err error) {
+			sub, err := task.relayer.SubscribeEvent(task.contractAddr, task.eventId, task.recCh)
+			if err != nil {
+				return err
+			}
+			task.sub = sub
+			return nil
+		})
+		if err == errMonitorTaskStopped {
+			task.stopped()
+			return err
+		}
+		if err != nil {
+			task.SetStatus(MonitorTaskStopped)
+			return err
+		}
+
+		// a stop-signal received by sendLog during the replay is handled there
+		buffered, err := task.bufferedBackfill()
+		if err == nil {
+			for i := range buffered {
+				if err = task.handleLog(&buffered[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err == errMonitorTaskStopped {
+			return err
+		}
+		log.Warn("MonitorTask::resubscribe() failed to replay the missed logs, resubscribing", "chainId", task.targetChainId, "event", task.eventName, "err", err.Error())
+	}
+}
+
+var errMonitorTaskStopped = errors.New("monitor task stopped")
+
+// stopped is called by the monitoring goroutine once it receives the stop-signal
+func (task *MonitorTask) stopped() {
+	log.Info("MonitorTask::Monitoring() receive the stop-signal", "chainId", task.TargetChainId(), "contract", task.contractAddr, "event", task.eventName)
+	task.sub.Unsubscribe()
+	//close(task.errCh)
+	//close(task.recCh)
+	//close(task.cancelCh)
+	task.SetStatus(MonitorTaskStopped)
+}
+
+// sendLog sends the log on, the filter of a route moves the checkpoint along with the job it records
+func (task *MonitorTask) sendLog(l *types.Log) error {
+	if task.filter != nil && !task.filter(l) {
+		return nil
+	}
+	if task.sendDataCh != nil {
+		log.Info("MonitorTask::Monitoring() sending data to next processing program", "chainId", task.targetChainId, "event", task.eventName, "txHash", l.TxHash, "logIndex", l.Index)
+		select {
+		case task.sendDataCh <- l:
+		case <-task.cancelCh:
+			task.stopped()
+			return errMonitorTaskStopped
+		}
+	}
+	return nil
+}
+
+func (task *MonitorTask) saveCheckpoint(number uint64) {
+	err := task.relayer.Checkpoints().Put(task.route, task.contractAddr, task.eventId, number)
+	if err != nil {
+		log.Error("MonitorTask::saveCheckpoint() failed to save checkpoint", "chainId", task.targetChainId, "route", task.route, "event", task.eventName, "block", number, "err", err.Error())
+	}
+}
+
+// BackfillBlockRange is the number of blocks queried by one eth_getLogs of the backfill
+const BackfillBlockRange = 2000
+
+// backfill sends on the logs from the checkpoint to the head via eth_getLogs, BackfillBlockRange blocks per query and
+// batchSize * batchConcurrency queries in flight, and moves the checkpoint after each round of queries. The subscription is made before the head is read, so the logs after
+// the head are delivered by the subscription.
+func (task *MonitorTask) backfill() error {
+	if task.route == "" {
+		return nil
+	}
+
+	head, err := task.relayer.LatestBlockNumber()
+	if err != nil {
+		return err
+	}
+
+	from := task.startBlock
+	checkpoint, ok, err := task.relayer.Checkpoints().Get(task.route, task.contractAddr, task.eventId)
+	if err != nil {
+		return err
+	}
+	if ok {
+		from = checkpoint + 1
+	} else if from == 0 {
+		// a new route without startBlock relays the logs from now on
+		task.saveCheckpoint(head)
+		return nil
+	}
+
+	if from <= head {
+		log.Info("MonitorTask::backfill() backfill logs", "chainId", task.targetChainId, "route", task.route, "event", task.eventName, "from", from, "to", head)
+	}
+	window := uint64(BackfillBlockRange * task.relayer.ChainConfig.batchSize * task.relayer.ChainConfig.batchConcurrency)
+	if window == 0 {
+		window = BackfillBlockRange
+	}
+	for start := from; start <= head; start += window {
+		end := start + window - 1
+		if end > head {
+			end = head
+		}
+
+		var logs []types.Log
+		for retry := 0; ; retry++ {
+			logs, err = task.relayer.LogsByRange(task.contractAddr, task.eventId, start, end)
+			if err == nil || retry >= RetryTimes {
+				break
+			}
+			log.Warn("MonitorTask::backfill() failed to filter logs, retrying", "chainId", task.targetChainId, "from", start, "to", end, "err", err.Error())
+			select {
+			case <-time.After(task.relayer.ChainConfig.PollInterval()):
+			case <-task.cancelCh:
+				task.stopped()
+				return errMonitorTaskStopped
+			case <-task.relayer.ctx.Done():
+				return errRelayerStopped
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		for i := range logs {
+			if err = task.sendLog(&logs[i]); err != nil {
+				return err
+			}
+		}
+		task.saveCheckpoint(end)
+	}
+	return nil
+}
+
 func (task *MonitorTask) Stop() error {
 	log.Info("MonitorTask::Stop() send the stop-signal to the monitor-contract-event task ", "chainId", task.TargetChainId(), "contract", task.contractAddr, "event", task.eventName)
 	if atomic.LoadUint32(&task.status) == MonitorTaskMonitoring {
@@ -160,17 +367,23 @@ func (task *MonitorTask) SetStatus(newStatus uint32) {
 	atomic.StoreUint32(&task.status, newStatus)
 }
 
-func (manager *TaskManager) GenMonitorEventTask(targetChainId uint64, address common.Address, eventName string) *MonitorTask {
-	eventId := GlobalContractInfo.GetContractEventId(targetChainId, address, eventName)
-	if len(eventId) == 0 {
-		panic(eventId)
+func (manager *TaskManager) GenMonitorEventTask(targetChainId uint64, address common.Address, eventName string) (*MonitorTask, error) {
+	contract := manager.contracts.GetContractByAddr(targetChainId, address)
+	if contract == nil {
+		return nil, fmt.Errorf("no contract deployed at %s on chain %d", address.Hex(), targetChainId)
 	}
-	// todo: how to judge the eventId validity
-	task := NewMonitorEventTask(manager.wg, targetChainId, address, eventName, eventId)
+	event, ok := contract.Abi.Events[eventName]
+	if !ok {
+		return nil, fmt.Errorf("event [%s] no exist at the abi of contract [%s]", eventName, contract.Name)
+	}
+	eventId := event.ID
+
+	task := NewMonitorEventTask(&manager.wg, targetChainId, address, eventName, eventId)
 	ef := func(c IChainRelayer) (err error) {
-		task.sub, err = c.(*EthChainRelayer).SubscribeEvent(address, eventId, task.recCh)
+		task.relayer = c.(*EthChainRelayer)
+		task.sub, err = task.relayer.SubscribeEvent(address, eventId, task.recCh)
 		return err
 	}
 	task.MonitorFunc = ef
-	return task
+	return task, nil
 }
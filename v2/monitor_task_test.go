@@ -0,0 +1,110 @@
+package v2
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMonitorTaskBufferedBackfill(t *testing.T) {
+	f := newFakeEth(5)
+	contract, eventId := common.HexToAddress("0x01"), common.HexToHash("0x02")
+	f.mine(types.Log{Address: contract, Topics: []common.Hash{eventId}, Data: []byte{}, TxHash: common.HexToHash("0x03")})
+	relayer := newPollingRelayer(t, f)
+	relayer.store = NewChainStore(NewMemoryStorage(), f.chainId, 0)
+
+	task := NewMonitorEventTask(&sync.WaitGroup{}, f.chainId, contract, "Event", eventId)
+	task.relayer, task.route, task.startBlock = relayer, "route", 1
+	sendCh := make(chan interface{})
+	if err := task.SubscribeData(sendCh); err != nil {
+		t.Fatal(err)
+	}
+	type result struct {
+		buffered []types.Log
+		err      error
+	}
+	done := make(chan result)
+	go func() {
+		buffered, err := task.bufferedBackfill()
+		done <- result{buffered, err}
+	}()
+
+	// the subscription is drained while the backfill waits to send its log on
+	live := types.Log{Address: contract, Topics: []common.Hash{eventId}, Data: []byte{}, TxHash: common.HexToHash("0x04")}
+	select {
+	case task.recCh <- live:
+	case <-time.After(time.Second):
+		t.Fatal("subscription blocked by the backfill")
+	}
+	select {
+	case l := <-sendCh:
+		if l.(*types.Log).TxHash != common.HexToHash("0x03") {
+			t.Fatalf("unexpected backfilled log %s", l.(*types.Log).TxHash.Hex())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no log backfilled")
+	}
+	res := <-done
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	if len(res.buffered) != 1 || res.buffered[0].TxHash != live.TxHash {
+		t.Fatalf("unexpected buffered logs %+v", res.buffered)
+	}
+}
+
+func TestMonitorTaskBackfillCheckpoint(t *testing.T) {
+	f := newFakeEth(5)
+	contract, eventId := common.HexToAddress("0x01"), common.HexToHash("0x02")
+	for i := 1; i <= 2*BackfillBlockRange+500; i++ {
+		var logs []types.Log
+		if i == 10 || i == 2*BackfillBlockRange+400 {
+			logs = append(logs, types.Log{Address: contract, Topics: []common.Hash{eventId}, Data: []byte{}, TxHash: common.BigToHash(big.NewInt(int64(i)))})
+		}
+		f.mine(logs...)
+	}
+	relayer := newPollingRelayer(t, f)
+	relayer.store = NewChainStore(NewMemoryStorage(), f.chainId, 0)
+	// a round of one batch of two queries
+	relayer.ChainConfig.batchSize, relayer.ChainConfig.batchConcurrency = 1, 2
+
+	task := NewMonitorEventTask(&sync.WaitGroup{}, f.chainId, contract, "Event", eventId)
+	task.relayer, task.route, task.startBlock = relayer, "route", 1
+	sendCh := make(chan interface{})
+	if err := task.SubscribeData(sendCh); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() {
+		done <- task.backfill()
+	}()
+
+	checkpoint := func() uint64 {
+		number, _, err := relayer.Checkpoints().Get("route", contract, eventId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return number
+	}
+	// the checkpoint is checked while the backfill waits to send the log of a round on
+	for _, want := range []uint64{0, 2 * BackfillBlockRange} {
+		time.Sleep(50 * time.Millisecond)
+		if got := checkpoint(); got != want {
+			t.Fatalf("checkpoint %d, want %d", got, want)
+		}
+		select {
+		case <-sendCh:
+		case <-time.After(time.Second):
+			t.Fatal("no log backfilled")
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if got := checkpoint(); got != 2*BackfillBlockRange+500 {
+		t.Fatalf("checkpoint %d after the backfill", got)
+	}
+}
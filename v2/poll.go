@@ -0,0 +1,96 @@
+package v2
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"time"
+)
+
+// pollHeads sends the header of every new block to ch like eth_subscribe newHeads does, polling eth_blockNumber every
+// pollInterval. The first header sent is the head at the time of the call. Failed polls are retried at the next tick.
+func (c *EthChainRelayer) pollHeads(ch chan<- *types.Header) (event.Subscription, error) {
+	next, err := c.LatestBlockNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		ticker := time.NewTicker(c.ChainConfig.pollInterval)
+		defer ticker.Stop()
+
+		for {
+			latest, err := c.LatestBlockNumber()
+			if err != nil {
+				log.Warn("EthChainRelayer::pollHeads() failed to poll the block number", "chainId", c.ChainId(), "err", err.Error())
+			}
+			for ; err == nil && next <= latest; next++ {
+				var header *types.Header
+				if header, err = c.GetSpecificHeader(next); err != nil {
+					log.Warn("EthChainRelayer::pollHeads() failed to get header", "chainId", c.ChainId(), "headerNum", next, "err", err.Error())
+					break
+				}
+				select {
+				case ch <- header:
+				case <-quit:
+					return nil
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// pollLogs sends the logs of the contract event to ch like eth_subscribe logs does, querying eth_getLogs for the new
+// blocks every pollInterval. Only the logs after the head at the time of the call are sent.
+func (c *EthChainRelayer) pollLogs(contract common.Address, eventId common.Hash, ch chan<- types.Log) (event.Subscription, error) {
+	head, err := c.LatestBlockNumber()
+	if err != nil {
+		return nil, err
+	}
+	next := head + 1
+
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		ticker := time.NewTicker(c.ChainConfig.pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-quit:
+				return nil
+			}
+
+			latest, err := c.LatestBlockNumber()
+			if err != nil {
+				log.Warn("EthChainRelayer::pollLogs() failed to poll the block number", "chainId", c.ChainId(), "err", err.Error())
+				continue
+			}
+			for next <= latest {
+				to := next + BackfillBlockRange - 1
+				if to > latest {
+					to = latest
+				}
+				logs, err := c.FilterLogs(contract, eventId, next, to)
+				if err != nil {
+					log.Warn("EthChainRelayer::pollLogs() failed to filter logs", "chainId", c.ChainId(), "from", next, "to", to, "err", err.Error())
+					break
+				}
+				for _, l := range logs {
+					select {
+					case ch <- l:
+					case <-quit:
+						return nil
+					}
+				}
+				next = to + 1
+			}
+		}
+	}), nil
+}
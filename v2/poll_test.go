@@ -0,0 +1,187 @@
+package v2
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeEth serves the eth namespace methods used by the polling monitors from an in-memory chain
+type fakeEth struct {
+	lock    sync.Mutex
+	chainId uint64
+	headers []*types.Header
+	logs    []types.Log
+	lookups []common.Hash // the txs asked by eth_getTransactionByHash
+}
+
+type fakeFilter struct {
+	FromBlock *hexutil.Big     `json:"fromBlock"`
+	ToBlock   *hexutil.Big     `json:"toBlock"`
+	Address   []common.Address `json:"address"`
+	Topics    [][]common.Hash  `json:"topics"`
+}
+
+func newFakeEth(chainId uint64) *fakeEth {
+	f := &fakeEth{chainId: chainId}
+	f.headers = makeHeaders(&types.Header{Number: big.NewInt(-1), Difficulty: big.NewInt(1)}, 1, 0)
+	return f
+}
+
+// mine appends a block holding logs
+func (f *fakeEth) mine(logs ...types.Log) *types.Header {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	header := makeHeaders(f.headers[len(f.headers)-1], 1, 0)[0]
+	f.headers = append(f.headers, header)
+	for _, l := range logs {
+		l.BlockNumber, l.BlockHash = header.Number.Uint64(), header.Hash()
+		f.logs = append(f.logs, l)
+	}
+	return header
+}
+
+func (f *fakeEth) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(new(big.Int).SetUint64(f.chainId))
+}
+
+func (f *fakeEth) BlockNumber() hexutil.Uint64 {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return hexutil.Uint64(len(f.headers) - 1)
+}
+
+func (f *fakeEth) GetBlockByNumber(number rpc.BlockNumber, full bool) (*types.Header, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if number == rpc.LatestBlockNumber {
+		return f.headers[len(f.headers)-1], nil
+	}
+	if number < 0 || int(number) >= len(f.headers) {
+		return nil, nil
+	}
+	return f.headers[number], nil
+}
+
+func (f *fakeEth) GetLogs(filter fakeFilter) ([]types.Log, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	logs := make([]types.Log, 0)
+	for _, l := range f.logs {
+		if l.BlockNumber < filter.FromBlock.ToInt().Uint64() || l.BlockNumber > filter.ToBlock.ToInt().Uint64() {
+			continue
+		}
+		if len(filter.Address) != 0 && l.Address != filter.Address[0] {
+			continue
+		}
+		if len(filter.Topics) != 0 && len(filter.Topics[0]) != 0 && l.Topics[0] != filter.Topics[0][0] {
+			continue
+		}
+		logs = append(logs, l)
+	}
+	return logs, nil
+}
+
+func (f *fakeEth) GetTransactionReceipt(txHash common.Hash) (*types.Receipt, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	var receipt *types.Receipt
+	for i := range f.logs {
+		l := f.logs[i]
+		if l.TxHash != txHash {
+			continue
+		}
+		if receipt == nil {
+			receipt = &types.Receipt{Status: types.ReceiptStatusSuccessful, TxHash: txHash, BlockHash: l.BlockHash, BlockNumber: new(big.Int).SetUint64(l.BlockNumber), Logs: []*types.Log{}}
+		}
+		receipt.Logs = append(receipt.Logs, &l)
+	}
+	return receipt, nil
+}
+
+// GetTransactionByHash records the lookup, the fake chain holds no txs
+func (f *fakeEth) GetTransactionByHash(txHash common.Hash) (*types.Transaction, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.lookups = append(f.lookups, txHash)
+	return nil, nil
+}
+
+// newPollingRelayer builds a relayer polling the fake chain over an in-process rpc connection
+func newPollingRelayer(t *testing.T, f *fakeEth) *EthChainRelayer {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", f); err != nil {
+		t.Fatal(err)
+	}
+	rpcClient := rpc.DialInProc(server)
+	client := ethclient.NewClient(rpcClient)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+		client.Close()
+		server.Stop()
+	})
+
+	conf := &ChainConfig{name: "fake", chainId: f.chainId, monitor: PollMonitor, pollInterval: 5 * time.Millisecond}
+	chainClient := &EthChainClient{chainId: f.chainId, wsClient: client, httpClient: client, wsRpc: rpcClient, httpRpc: rpcClient}
+	return &EthChainRelayer{ChainConfig: conf, chainClient: chainClient, heads: NewHeadTracker(), ctx: ctx, cancel: cancel}
+}
+
+func TestPollHeadsAndLogs(t *testing.T) {
+	f := newFakeEth(5)
+	f.mine()
+	relayer := newPollingRelayer(t, f)
+
+	headCh := make(chan *types.Header)
+	headSub, err := relayer.pollHeads(headCh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer headSub.Unsubscribe()
+	contract, eventId := common.HexToAddress("0x01"), common.HexToHash("0x02")
+	logCh := make(chan types.Log)
+	logSub, err := relayer.SubscribeEvent(contract, eventId, logCh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logSub.Unsubscribe()
+
+	recvHeader := func(number uint64) {
+		select {
+		case header := <-headCh:
+			if header.Number.Uint64() != number {
+				t.Fatalf("got header %d, want %d", header.Number.Uint64(), number)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no header %d polled", number)
+		}
+	}
+	recvHeader(1)
+
+	other := types.Log{Address: common.HexToAddress("0x03"), Topics: []common.Hash{eventId}, Data: []byte{}}
+	mined := f.mine(types.Log{Address: contract, Topics: []common.Hash{eventId}, Data: []byte{1}, TxHash: common.HexToHash("0x04")}, other)
+	f.mine()
+	recvHeader(2)
+	recvHeader(3)
+
+	select {
+	case l := <-logCh:
+		if l.TxHash != common.HexToHash("0x04") || l.BlockHash != mined.Hash() {
+			t.Fatalf("unexpected log %+v", l)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no log polled")
+	}
+	select {
+	case l := <-logCh:
+		t.Fatalf("unexpected second log %+v", l)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,117 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type CheckResult struct {
+	Name string
+	Err  error
+}
+
+// PreflightCheck validates cfg against the live chains: the chainId behind every rpc endpoint, the block tag of the
+// finality policy if any, the bytecode at the configured contract addresses and the abi items used by the routes.
+// A result is returned per check.
+func PreflightCheck(ctx context.Context, cfg *Config) []*CheckResult {
+	results := make([]*CheckResult, 0)
+	report := func(err error, format string, args ...interface{}) {
+		results = append(results, &CheckResult{Name: fmt.Sprintf(format, args...), Err: err})
+	}
+
+	for _, chain := range cfg.Chains {
+		var client *ethclient.Client
+		for _, url := range append(append([]string{}, chain.httpRpcs...), chain.wssRpcs...) {
+			c, err := checkEndpointChainId(ctx, url, chain.chainId)
+			report(err, "chain [%s] rpc %s serves chainId %d", chain.name, url, chain.chainId)
+			if err != nil {
+				continue
+			}
+			if policy := chain.Finality().Policy; policy == FinalizedFinality || policy == SafeFinality {
+				report(checkBlockTag(ctx, url, policy), "chain [%s] rpc %s serves the %s block", chain.name, url, policy)
+			}
+			if client == nil {
+				client = c
+			} else {
+				c.Close()
+			}
+		}
+
+		if client == nil {
+			report(errors.New("no reachable rpc endpoint"), "chain [%s] contract bytecode", chain.name)
+			continue
+		}
+
+		for _, contract := range cfg.Contracts {
+			if contract.ChainId == chain.chainId {
+				report(checkCodeAt(ctx, client, contract.Address), "chain [%s] bytecode exists at contract [%s] %s", chain.name, contract.Name, contract.Address.Hex())
+			}
+		}
+		client.Close()
+	}
+
+	contracts, err := NewContractsConfig(cfg.Contracts)
+	report(err, "contract abis can be loaded")
+	if err != nil {
+		return results
+	}
+
+	for _, route := range cfg.Routes {
+		_, err = NewRoute(route, contracts)
+		report(err, "route [%s] matches the contract abis", route.Name)
+	}
+
+	return results
+}
+
+func checkEndpointChainId(ctx context.Context, url string, expect uint64) (*ethclient.Client, error) {
+	client, err := ethclient.DialContext(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	chainId, err := client.ChainID(ctx)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	if chainId.Uint64() != expect {
+		client.Close()
+		return nil, fmt.Errorf("rpc serves chainId %d", chainId.Uint64())
+	}
+	return client, nil
+}
+
+func checkCodeAt(ctx context.Context, client *ethclient.Client, addr common.Address) error {
+	code, err := client.CodeAt(ctx, addr, nil)
+	if err != nil {
+		return err
+	}
+	if len(code) == 0 {
+		return fmt.Errorf("no bytecode at %s", addr.Hex())
+	}
+	return nil
+}
+
+func checkBlockTag(ctx context.Context, url string, tag string) error {
+	client, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	var header *types.Header
+	if err = client.CallContext(ctx, &header, "eth_getBlockByNumber", tag, false); err != nil {
+		return err
+	}
+	if header == nil {
+		return fmt.Errorf("no %s block", tag)
+	}
+	return nil
+}
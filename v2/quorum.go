@@ -0,0 +1,301 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultQuorumTimeout         = 5 * time.Minute
+	DefaultQuorumRetryInterval   = 5 * time.Second
+	DefaultQuorumRecheckInterval = time.Minute
+	DefaultQuorumDialTimeout     = 10 * time.Second
+	// QuorumConcurrency is the number of logs of a route verified at once
+	QuorumConcurrency = 16
+)
+
+// QuorumConfig makes every log observed at a chain wait for threshold of the rpcs, independent of the endpoints the
+// chain is monitored with, to return the same log before its job proceeds. Disagreements are posted to alertUrl.
+type QuorumConfig struct {
+	Rpcs      rpcUrls `json:"rpcs"`
+	Threshold int     `json:"threshold"`
+	Timeout   string  `json:"timeout,omitempty"` // how long a log may wait for its confirmations, defaults to 5m
+	AlertUrl  string  `json:"alertUrl,omitempty"`
+}
+
+func (c *QuorumConfig) validate() error {
+	if len(c.Rpcs) == 0 {
+		return errors.New("quorum without rpcs")
+	}
+	for _, url := range c.Rpcs {
+		if url == "" {
+			return errors.New("quorum with empty rpc url")
+		}
+	}
+	if c.Threshold < 1 || c.Threshold > len(c.Rpcs) {
+		return fmt.Errorf("quorum threshold %d out of 1-%d", c.Threshold, len(c.Rpcs))
+	}
+	if c.Timeout != "" {
+		if d, err := time.ParseDuration(c.Timeout); err != nil || d <= 0 {
+			return fmt.Errorf("quorum with invalid timeout [%s]", c.Timeout)
+		}
+	}
+	return nil
+}
+
+func (c *QuorumConfig) timeout() time.Duration {
+	if d, err := time.ParseDuration(c.Timeout); err == nil {
+		return d
+	}
+	return DefaultQuorumTimeout
+}
+
+// QuorumDisagreement is a provider that returned another log than the one observed, or none at all
+type QuorumDisagreement struct {
+	Provider string     `json:"provider"`
+	Reason   string     `json:"reason"`
+	Log      *types.Log `json:"log,omitempty"` // the log returned by the provider
+	At       int64      `json:"at"`
+}
+
+// QuorumResult is the outcome of the verification of a log
+type QuorumResult struct {
+	Confirmations int
+	Threshold     int
+	Disagreements []*QuorumDisagreement
+}
+
+func (r *QuorumResult) Verified() bool {
+	return r.Confirmations >= r.Threshold
+}
+
+type quorumProvider struct {
+	url    string
+	client *ethclient.Client
+}
+
+// QuorumVerifier confirms the logs observed at a chain with the providers of its QuorumConfig
+type QuorumVerifier struct {
+	chainId   uint64
+	providers []*quorumProvider
+	threshold int
+	timeout   time.Duration
+	alertUrl  string
+
+	// retryInterval is the wait before the providers that have not returned the log yet are asked again
+	retryInterval time.Duration
+	// recheckInterval is the wait before the logs that failed their verification are verified again
+	recheckInterval time.Duration
+	// dialTimeout bounds the dial of a provider
+	dialTimeout time.Duration
+	lock        sync.Mutex
+}
+
+func NewQuorumVerifier(chainId uint64, conf *QuorumConfig) *QuorumVerifier {
+	v := &QuorumVerifier{
+		chainId:         chainId,
+		threshold:       conf.Threshold,
+		timeout:         conf.timeout(),
+		alertUrl:        conf.AlertUrl,
+		retryInterval:   DefaultQuorumRetryInterval,
+		recheckInterval: DefaultQuorumRecheckInterval,
+		dialTimeout:     DefaultQuorumDialTimeout,
+	}
+	for _, url := range conf.Rpcs {
+		v.providers = append(v.providers, &quorumProvider{url: url})
+	}
+	return v
+}
+
+// client dials the provider on first use, so that an unreachable provider only costs its confirmation. The dial runs
+// outside the lock, so that a hanging provider does not hold up the others.
+func (v *QuorumVerifier) client(pctx context.Context, p *quorumProvider) (*ethclient.Client, error) {
+	v.lock.Lock()
+	client := p.client
+	v.lock.Unlock()
+	if client != nil {
+		return client, nil
+	}
+
+	ctx, cancel := context.WithTimeout(pctx, v.dialTimeout)
+	defer cancel()
+	client, err := ethclient.DialContext(ctx, p.url)
+	if err != nil {
+		return nil, err
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if p.client != nil {
+		// dialed by another verification meanwhile
+		client.Close()
+		return p.client, nil
+	}
+	p.client = client
+	return client, nil
+}
+
+// check asks the provider for the receipt of the log. It returns a disagreement if the provider holds another log, a
+// nil disagreement and nil error if it confirms the log, and errReceiptNotFound if it does not know the tx (yet).
+func (v *QuorumVerifier) check(ctx context.Context, p *quorumProvider, l *types.Log) (*QuorumDisagreement, error) {
+	client, err := v.client(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	receipt, err := client.TransactionReceipt(ctx, l.TxHash)
+	if err == ethereum.NotFound {
+		return nil, errReceiptNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	disagree := func(reason string, got *types.Log) (*QuorumDisagreement, error) {
+		return &QuorumDisagreement{Provider: p.url, Reason: reason, Log: got, At: time.Now().Unix()}, nil
+	}
+	if receipt.BlockHash != l.BlockHash {
+		return disagree(fmt.Sprintf("tx included at block %s", receipt.BlockHash.Hex()), nil)
+	}
+	for _, got := range receipt.Logs {
+		if got.Index != l.Index {
+			continue
+		}
+		if !sameLog(got, l) {
+			return disagree("log differs", got)
+		}
+		return nil, nil
+	}
+	return disagree(fmt.Sprintf("tx without log %d", l.Index), nil)
+}
+
+var errReceiptNotFound = errors.New("receipt not found")
+
+// sameLog compares the block hash, tx hash, index, address, topics and data of the logs
+func sameLog(a, b *types.Log) bool {
+	if a.BlockHash != b.BlockHash || a.TxHash != b.TxHash || a.Index != b.Index || a.Address != b.Address {
+		return false
+	}
+	if len(a.Topics) != len(b.Topics) {
+		return false
+	}
+	for i := range a.Topics {
+		if a.Topics[i] != b.Topics[i] {
+			return false
+		}
+	}
+	return bytes.Equal(a.Data, b.Data)
+}
+
+// Verify asks the providers for the log until threshold of them confirm it, too many of them disagree for the
+// threshold to be reached, or the timeout passes. Providers that fail or do not know the tx yet are asked again
+// every retryInterval, the ones left at the timeout are counted as disagreements.
+func (v *QuorumVerifier) Verify(ctx context.Context, l *types.Log) (*QuorumResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+
+	res := &QuorumResult{Threshold: v.threshold}
+	pending := v.providers
+	lastErrs := make(map[*quorumProvider]error)
+	for {
+		type answer struct {
+			p        *quorumProvider
+			disagree *QuorumDisagreement
+			err      error
+		}
+		answers := make(chan answer, len(pending))
+		for _, p := range pending {
+			go func(p *quorumProvider) {
+				d, err := v.check(ctx, p, l)
+				answers <- answer{p, d, err}
+			}(p)
+		}
+
+		var retry []*quorumProvider
+		for range pending {
+			a := <-answers
+			switch {
+			case a.err != nil:
+				// keep the reason the provider failed with before the timeout cut its last call short
+				if _, ok := lastErrs[a.p]; !ok || ctx.Err() == nil {
+					lastErrs[a.p] = a.err
+				}
+				retry = append(retry, a.p)
+			case a.disagree != nil:
+				res.Disagreements = append(res.Disagreements, a.disagree)
+			default:
+				res.Confirmations++
+			}
+		}
+		pending = retry
+		if res.Verified() || res.Confirmations+len(pending) < v.threshold {
+			return res, nil
+		}
+
+		select {
+		case <-time.After(v.retryInterval):
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				for _, p := range pending {
+					res.Disagreements = append(res.Disagreements, &QuorumDisagreement{Provider: p.url, Reason: lastErrs[p].Error(), At: time.Now().Unix()})
+				}
+				return res, nil
+			}
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Alert reports the disagreements on the log of a route, posting them to the alertUrl if configured
+func (v *QuorumVerifier) Alert(route string, l *types.Log, res *QuorumResult) {
+	for _, d := range res.Disagreements {
+		log.Error("QuorumVerifier::Alert() provider disagrees on the observed log", "chainId", v.chainId, "route", route, "txHash", l.TxHash, "logIndex", l.Index, "provider", d.Provider, "reason", d.Reason, "verified", res.Verified())
+	}
+	if v.alertUrl == "" {
+		return
+	}
+
+	b, err := json.Marshal(map[string]interface{}{
+		"chainId":       v.chainId,
+		"route":         route,
+		"log":           l,
+		"verified":      res.Verified(),
+		"confirmations": res.Confirmations,
+		"threshold":     res.Threshold,
+		"disagreements": res.Disagreements,
+	})
+	if err != nil {
+		log.Error("QuorumVerifier::Alert() failed to encode alert", "chainId", v.chainId, "err", err.Error())
+		return
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(v.alertUrl, "application/json", bytes.NewReader(b))
+	if err != nil {
+		log.Error("QuorumVerifier::Alert() failed to post alert", "chainId", v.chainId, "alertUrl", v.alertUrl, "err", err.Error())
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		log.Error("QuorumVerifier::Alert() alert rejected", "chainId", v.chainId, "alertUrl", v.alertUrl, "status", resp.Status)
+	}
+}
+
+func (v *QuorumVerifier) Close() {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	for _, p := range v.providers {
+		if p.client != nil {
+			p.client.Close()
+			p.client = nil
+		}
+	}
+}
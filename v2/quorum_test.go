@@ -0,0 +1,157 @@
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestQuorumVerify(t *testing.T) {
+	observed := types.Log{Address: common.HexToAddress("0x01"), Topics: []common.Hash{common.HexToHash("0x02")}, Data: []byte{1}, TxHash: common.HexToHash("0x03"), Index: 0}
+	forged := observed
+	forged.Data = []byte{2}
+
+	// the providers mine the same blocks, the last one holds a forged log
+	var providers []string
+	var logs []*types.Log
+	for _, l := range []types.Log{observed, observed, forged} {
+		f := newFakeEth(5)
+		header := f.mine(l)
+		providers = append(providers, serveFakeEth(t, f).URL)
+		mined := l
+		mined.BlockNumber, mined.BlockHash = header.Number.Uint64(), header.Hash()
+		logs = append(logs, &mined)
+	}
+	lagging := serveFakeEth(t, newFakeEth(5)).URL
+
+	alerts := make(chan map[string]interface{}, 10)
+	alertServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		alert := make(map[string]interface{})
+		json.NewDecoder(r.Body).Decode(&alert)
+		alerts <- alert
+	}))
+	defer alertServer.Close()
+
+	newVerifier := func(threshold int, timeout string, rpcs ...string) *QuorumVerifier {
+		conf := &QuorumConfig{Rpcs: rpcs, Threshold: threshold, Timeout: timeout, AlertUrl: alertServer.URL}
+		if err := conf.validate(); err != nil {
+			t.Fatal(err)
+		}
+		v := NewQuorumVerifier(5, conf)
+		v.retryInterval = 10 * time.Millisecond
+		t.Cleanup(v.Close)
+		return v
+	}
+
+	// 2 of 3 confirm, the forged provider is recorded
+	v := newVerifier(2, "", providers...)
+	res, err := v.Verify(context.Background(), logs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Verified() || res.Confirmations != 2 || len(res.Disagreements) != 1 || res.Disagreements[0].Provider != providers[2] {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if got := res.Disagreements[0].Log; got == nil || got.Data[0] != 2 {
+		t.Fatalf("the forged log is not recorded: %+v", res.Disagreements[0])
+	}
+	v.Alert("route", logs[0], res)
+	select {
+	case alert := <-alerts:
+		if alert["verified"] != true || alert["route"] != "route" {
+			t.Fatalf("unexpected alert %v", alert)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no alert posted")
+	}
+
+	// 3 of 3 can not be reached once the forged provider disagrees, no need to wait for the timeout
+	v = newVerifier(3, "1h", providers...)
+	if res, err = v.Verify(context.Background(), logs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if res.Verified() || res.Confirmations != 2 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	// a provider without the tx is waited for until the timeout
+	v = newVerifier(2, "100ms", providers[0], lagging)
+	if res, err = v.Verify(context.Background(), logs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if res.Verified() || len(res.Disagreements) != 1 || res.Disagreements[0].Reason != errReceiptNotFound.Error() {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	store := NewChainStore(NewMemoryStorage(), 5, 0)
+	if _, err = store.Jobs.Observe("route", logs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.Jobs.RecordQuorum("route", logs[0], res); err != nil {
+		t.Fatal(err)
+	}
+	job, err := store.Jobs.Get("route", logs[0].TxHash, logs[0].Index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Stage != JobObserved || job.LastError == "" || len(job.Disagreements) != 1 {
+		t.Fatalf("unexpected job %+v", job)
+	}
+}
+
+func TestQuorumVerifierDialTimeout(t *testing.T) {
+	// the hanging provider accepts the connection but never answers the ws handshake
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	up := serveFakeEth(t, newFakeEth(5)).URL
+
+	v := NewQuorumVerifier(5, &QuorumConfig{Rpcs: rpcUrls{"ws://" + ln.Addr().String(), up}, Threshold: 1})
+	v.dialTimeout = 100 * time.Millisecond
+	defer v.Close()
+	done := make(chan error)
+	go func() {
+		_, err := v.client(context.Background(), v.providers[0])
+		done <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	got := make(chan error)
+	go func() {
+		_, err := v.client(context.Background(), v.providers[1])
+		got <- err
+	}()
+	select {
+	case err = <-got:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("provider blocked by the dial of another one")
+	}
+	select {
+	case err = <-done:
+		if err == nil {
+			t.Fatal("dialed a provider that never answers")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("dial not bounded by the dial timeout")
+	}
+}
@@ -0,0 +1,204 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Priority orders the calls waiting for the rate limit of an endpoint, a call never waits behind a lower priority
+type Priority int
+
+const (
+	PriorityTx   Priority = iota // building and sending txs
+	PriorityLive                 // following the heads and logs of the chain
+	PriorityBulk                 // backfilling logs and replaying headers
+
+	priorityClasses = 3
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityTx:
+		return "tx"
+	case PriorityLive:
+		return "live"
+	case PriorityBulk:
+		return "bulk"
+	default:
+		return fmt.Sprintf("priority-%d", int(p))
+	}
+}
+
+// RateLimitConfig limits each endpoint of a chain to requestsPerSecond, bursting up to burst requests, and to
+// dailyQuota requests per UTC day. Zero means no limit.
+type RateLimitConfig struct {
+	RequestsPerSecond float64 `json:"requestsPerSecond,omitempty"`
+	Burst             int     `json:"burst,omitempty"` // defaults to requestsPerSecond, at least 1
+	DailyQuota        uint64  `json:"dailyQuota,omitempty"`
+}
+
+func (c *RateLimitConfig) validate() error {
+	if c.RequestsPerSecond < 0 {
+		return errors.New("negative requestsPerSecond")
+	}
+	if c.Burst < 0 {
+		return errors.New("negative burst")
+	}
+	return nil
+}
+
+var errQuotaExhausted = errors.New("daily quota of the endpoint exhausted")
+
+// RateUsage are the counters of a rate limiter
+type RateUsage struct {
+	Requests   uint64            `json:"requests"` // since start
+	Today      uint64            `json:"today"`
+	DailyQuota uint64            `json:"dailyQuota,omitempty"`
+	Throttled  uint64            `json:"throttled"` // requests that waited for the rate limit
+	Rejected   uint64            `json:"rejected"`  // requests refused by the daily quota
+	Waiting    map[string]int    `json:"waiting"`   // requests waiting now, by priority
+	ByPriority map[string]uint64 `json:"byPriority"`
+}
+
+// RateLimiter is a token bucket shared by the calls to one endpoint, the tokens go to the waiting calls of the
+// highest priority first
+type RateLimiter struct {
+	rate  float64
+	burst float64
+	quota uint64
+
+	lock       sync.Mutex
+	tokens     float64
+	last       time.Time
+	day        int64
+	today      uint64
+	requests   uint64
+	throttled  uint64
+	rejected   uint64
+	waiting    [priorityClasses]int
+	byPriority [priorityClasses]uint64
+}
+
+// NewRateLimiter returns a limiter counting the requests, it only limits them if conf sets a rate or a quota
+func NewRateLimiter(conf *RateLimitConfig) *RateLimiter {
+	l := &RateLimiter{last: time.Now()}
+	if conf != nil {
+		l.rate, l.burst, l.quota = conf.RequestsPerSecond, float64(conf.Burst), conf.DailyQuota
+		if l.burst == 0 {
+			l.burst = l.rate
+		}
+		if l.burst < 1 {
+			l.burst = 1
+		}
+	}
+	l.tokens = l.burst
+	return l
+}
+
+func (l *RateLimiter) refill(now time.Time) {
+	if day := now.Unix() / 86400; day != l.day {
+		l.day, l.today = day, 0
+	}
+	if l.rate > 0 {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+}
+
+func (l *RateLimiter) higherWaiting(p Priority) bool {
+	for i := Priority(0); i < p; i++ {
+		if l.waiting[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Wait blocks until the endpoint may take calls more requests. It fails at once if the daily quota is exhausted.
+func (l *RateLimiter) Wait(ctx context.Context, p Priority, calls int) error {
+	if p < 0 || p >= priorityClasses {
+		p = PriorityBulk
+	}
+	cost := float64(calls)
+	if cost > l.burst {
+		cost = l.burst
+	}
+
+	waiting := false
+	defer func() {
+		if waiting {
+			l.lock.Lock()
+			l.waiting[p]--
+			l.lock.Unlock()
+		}
+	}()
+	for {
+		l.lock.Lock()
+		l.refill(time.Now())
+		if l.quota > 0 && l.today+uint64(calls) > l.quota {
+			l.rejected += uint64(calls)
+			l.lock.Unlock()
+			return errQuotaExhausted
+		}
+		if l.rate <= 0 || (l.tokens >= cost && !l.higherWaiting(p)) {
+			if l.rate > 0 {
+				l.tokens -= cost
+			}
+			l.today += uint64(calls)
+			l.requests += uint64(calls)
+			l.byPriority[p] += uint64(calls)
+			l.lock.Unlock()
+			return nil
+		}
+		if !waiting {
+			waiting = true
+			l.waiting[p]++
+			l.throttled += uint64(calls)
+		}
+		delay := time.Duration(float64(time.Second) / l.rate)
+		if l.tokens < cost {
+			delay = time.Duration((cost - l.tokens) / l.rate * float64(time.Second))
+		}
+		l.lock.Unlock()
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Exhausted tells if the daily quota is used up
+func (l *RateLimiter) Exhausted() bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.refill(time.Now())
+	return l.quota > 0 && l.today >= l.quota
+}
+
+func (l *RateLimiter) Usage() *RateUsage {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.refill(time.Now())
+	usage := &RateUsage{
+		Requests:   l.requests,
+		Today:      l.today,
+		DailyQuota: l.quota,
+		Throttled:  l.throttled,
+		Rejected:   l.rejected,
+		Waiting:    make(map[string]int),
+		ByPriority: make(map[string]uint64),
+	}
+	for p := Priority(0); p < priorityClasses; p++ {
+		usage.Waiting[p.String()] = l.waiting[p]
+		usage.ByPriority[p.String()] = l.byPriority[p]
+	}
+	return usage
+}
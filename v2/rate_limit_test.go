@@ -0,0 +1,60 @@
+package v2
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterPriority(t *testing.T) {
+	limiter := NewRateLimiter(&RateLimitConfig{RequestsPerSecond: 20, Burst: 1})
+	if err := limiter.Wait(context.Background(), PriorityBulk, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// the bucket is empty, bulk reads queue up before a tx arrives
+	var lock sync.Mutex
+	var order []Priority
+	var wg sync.WaitGroup
+	wait := func(p Priority) {
+		defer wg.Done()
+		if err := limiter.Wait(context.Background(), p, 1); err != nil {
+			t.Error(err)
+			return
+		}
+		lock.Lock()
+		order = append(order, p)
+		lock.Unlock()
+	}
+	wg.Add(4)
+	for i := 0; i < 3; i++ {
+		go wait(PriorityBulk)
+	}
+	time.Sleep(2 * time.Millisecond)
+	go wait(PriorityTx)
+	wg.Wait()
+
+	if len(order) != 4 || order[0] != PriorityTx {
+		t.Fatalf("the tx queued behind the bulk reads: %v", order)
+	}
+	usage := limiter.Usage()
+	if usage.Requests != 5 || usage.ByPriority["tx"] != 1 || usage.Throttled == 0 {
+		t.Fatalf("unexpected usage %+v", usage)
+	}
+}
+
+func TestRateLimiterQuota(t *testing.T) {
+	limiter := NewRateLimiter(&RateLimitConfig{DailyQuota: 2})
+	for i := 0; i < 2; i++ {
+		if err := limiter.Wait(context.Background(), PriorityLive, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := limiter.Wait(context.Background(), PriorityTx, 1); err != errQuotaExhausted {
+		t.Fatalf("quota not enforced: %v", err)
+	}
+	if !limiter.Exhausted() || limiter.Usage().Rejected != 1 {
+		t.Fatalf("unexpected usage %+v", limiter.Usage())
+	}
+}
@@ -0,0 +1,241 @@
+package v2
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/log"
+	"reflect"
+	"sync/atomic"
+)
+
+// configDiff is what a reload changes: the chains whose relayer is closed or created and the routes whose tasks
+// are stopped or built. A changed chain or route is both removed and added, routes of a changed chain are rebuilt.
+type configDiff struct {
+	removedChains []uint64
+	addedChains   []*ChainConfig
+	removedRoutes []string
+	addedRoutes   []*RouteConfig
+}
+
+func (cfg *Config) contract(name string) *ContractConfig {
+	for _, contract := range cfg.Contracts {
+		if contract.Name == name {
+			return contract
+		}
+	}
+	return nil
+}
+
+func (cfg *Config) route(name string) *RouteConfig {
+	for _, route := range cfg.Routes {
+		if route.Name == name {
+			return route
+		}
+	}
+	return nil
+}
+
+func diffConfig(old, cfg *Config) *configDiff {
+	diff := new(configDiff)
+
+	changedChains := make(map[uint64]bool)
+	for _, oc := range old.Chains {
+		if nc := cfg.ChainById(oc.chainId); nc == nil || !reflect.DeepEqual(nc, oc) {
+			diff.removedChains = append(diff.removedChains, oc.chainId)
+			changedChains[oc.chainId] = true
+		}
+	}
+	for _, nc := range cfg.Chains {
+		if oc := old.ChainById(nc.chainId); oc == nil || !reflect.DeepEqual(oc, nc) {
+			diff.addedChains = append(diff.addedChains, nc)
+		}
+	}
+
+	unchanged := func(route *RouteConfig) bool {
+		oldRoute := old.route(route.Name)
+		if oldRoute == nil || !reflect.DeepEqual(oldRoute, route) {
+			return false
+		}
+		names := []string{route.Source.Contract, route.Target.Contract}
+		if route.HeaderRelay != nil {
+			names = append(names, route.HeaderRelay.Contract)
+		}
+		for _, name := range names {
+			oc, nc := old.contract(name), cfg.contract(name)
+			if oc == nil || nc == nil || !reflect.DeepEqual(oc, nc) || changedChains[nc.ChainId] {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, route := range old.Routes {
+		if nr := cfg.route(route.Name); nr == nil || !unchanged(nr) {
+			diff.removedRoutes = append(diff.removedRoutes, route.Name)
+		}
+	}
+	for _, route := range cfg.Routes {
+		if !unchanged(route) {
+			diff.addedRoutes = append(diff.addedRoutes, route)
+		}
+	}
+	return diff
+}
+
+// Reload applies cfg to the running coordinator: the tasks of removed or changed routes are stopped, the relayers of
+// removed or changed chains are closed, new chains get a relayer and new routes get their tasks. Unchanged routes
+// keep running. A changed key only applies to the relayers created by the reload, a changed storage is ignored.
+// The new relayers are built before anything running is touched, and if a route fails to start the reload is rolled
+// back to the previous config.
+func (c *Coordinator) Reload(cfg *Config) error {
+	c.reloadLock.Lock()
+	defer c.reloadLock.Unlock()
+
+	if c.cfg == nil {
+		return fmt.Errorf("Coordinator::Reload() coordinator was not built from config")
+	}
+	contracts, err := NewContractsConfig(cfg.Contracts)
+	if err != nil {
+		return err
+	}
+	for _, route := range cfg.Routes {
+		r, err := NewRoute(route, contracts)
+		if err != nil {
+			return err
+		}
+		for _, chainId := range []uint64{r.SourceChainId(), r.TargetChainId()} {
+			if cfg.ChainById(chainId) == nil {
+				return fmt.Errorf("route [%s] chain %d no exist", route.Name, chainId)
+			}
+		}
+	}
+
+	diff := diffConfig(c.cfg, cfg)
+	passwd := ""
+	if len(diff.addedChains) != 0 {
+		if passwd, err = cfg.Key.KeyPassword(); err != nil {
+			return err
+		}
+	}
+	if !reflect.DeepEqual(c.cfg.Key, cfg.Key) {
+		log.Warn("Coordinator::Reload() the key changed, it only applies to the chain relayers created from now on")
+	}
+	if c.cfg.Storage != cfg.Storage {
+		log.Warn("Coordinator::Reload() the storage changed, it only applies after a restart")
+	}
+
+	added := make([]IChainRelayer, 0, len(diff.addedChains))
+	for _, chainConf := range diff.addedChains {
+		log.Info("Coordinator::Reload() add chain relayer", "chain", chainConf.name, "chainId", chainConf.chainId)
+		relayer, err := NewEthChainRelayer(c.Context(), cfg.Key.Keystore, passwd, chainConf, contracts, c.storage)
+		if err != nil {
+			closeRelayers(added)
+			return err
+		}
+		added = append(added, relayer)
+	}
+
+	// undo holds the steps reverting the changes applied so far, in the order they were applied
+	var undo []func()
+	rollback := func(err error) error {
+		log.Error("Coordinator::Reload() failed to apply the config, rolling back", "err", err.Error())
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+		}
+		return err
+	}
+
+	for _, name := range diff.removedRoutes {
+		log.Info("Coordinator::Reload() remove route", "route", name)
+		if err = c.taskManager.RemoveRoute(name); err != nil {
+			closeRelayers(added)
+			return rollback(err)
+		}
+		route := c.cfg.route(name)
+		undo = append(undo, func() {
+			if err := c.AddRoute(route); err != nil {
+				log.Error("Coordinator::Reload() failed to restore route", "route", route.Name, "err", err.Error())
+			}
+		})
+	}
+
+	// the relayers of the removed chains keep running until the reload succeeds
+	removed := c.swapRelayers(diff.removedChains, added)
+	oldContracts := c.setContracts(contracts)
+	undo = append(undo, func() {
+		c.setContracts(oldContracts)
+		chainIds := make([]uint64, 0, len(added))
+		for _, relayer := range added {
+			chainIds = append(chainIds, relayer.ChainId())
+		}
+		c.swapRelayers(chainIds, removed)
+		closeRelayers(added)
+	})
+	if atomic.LoadUint32(&c.status) == CoordinatorDoing {
+		for _, relayer := range added {
+			c.startChainRelayer(relayer)
+		}
+	}
+
+	for _, route := range diff.addedRoutes {
+		log.Info("Coordinator::Reload() add route", "route", route.Name)
+		if err = c.AddRoute(route); err != nil {
+			return rollback(err)
+		}
+		name := route.Name
+		undo = append(undo, func() {
+			if err := c.taskManager.RemoveRoute(name); err != nil {
+				log.Error("Coordinator::Reload() failed to remove route", "route", name, "err", err.Error())
+			}
+		})
+	}
+
+	for _, relayer := range removed {
+		log.Info("Coordinator::Reload() remove chain relayer", "chainId", relayer.ChainId())
+	}
+	closeRelayers(removed)
+	c.cfg = cfg
+	return nil
+}
+
+// swapRelayers replaces the relayers of the chains with the given ones, it returns the replaced relayers
+func (c *Coordinator) swapRelayers(chainIds []uint64, relayers []IChainRelayer) []IChainRelayer {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	replaced := make([]IChainRelayer, 0, len(chainIds))
+	for _, chainId := range chainIds {
+		if relayer, ok := c.relayers[chainId]; ok {
+			replaced = append(replaced, relayer)
+			delete(c.relayers, chainId)
+		}
+	}
+	for _, relayer := range relayers {
+		c.relayers[relayer.ChainId()] = relayer
+	}
+	return replaced
+}
+
+// setContracts hands contracts to the relayers and the task manager, it returns the contracts replaced
+func (c *Coordinator) setContracts(contracts ContractsConfig) ContractsConfig {
+	c.lock.Lock()
+	old := c.contracts
+	c.contracts = contracts
+	for _, relayer := range c.relayers {
+		if r, ok := relayer.(*EthChainRelayer); ok {
+			r.SetContracts(contracts)
+		}
+	}
+	c.lock.Unlock()
+	c.taskManager.SetContracts(contracts)
+	return old
+}
+
+// closeRelayers stops and closes relayers that no longer belong to the coordinator
+func closeRelayers(relayers []IChainRelayer) {
+	for _, relayer := range relayers {
+		// a relayer that was never started fails to stop, Close cancels it all the same
+		_ = relayer.Stop()
+		if err := relayer.Close(); err != nil {
+			log.Warn("Coordinator::Reload() failed to close chain relayer", "chainId", relayer.ChainId(), "err", err.Error())
+		}
+	}
+}
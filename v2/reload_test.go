@@ -0,0 +1,164 @@
+package v2
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestDiffConfig(t *testing.T) {
+	load := func() *Config {
+		cfg, err := LoadConfig("../config.example.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cfg
+	}
+
+	old := load()
+	diff := diffConfig(old, load())
+	if len(diff.removedChains)+len(diff.addedChains)+len(diff.removedRoutes)+len(diff.addedRoutes) != 0 {
+		t.Fatalf("unexpected diff of the same config %+v", diff)
+	}
+
+	// a new route on the unchanged chains leaves the existing route running
+	cfg := load()
+	cfg.Routes = append(cfg.Routes, &RouteConfig{
+		Name:   "eth-to-w3q",
+		Source: RouteSource{Contract: EthereumBridgeContract, Event: ETHEventSendTokenName},
+		Target: RouteTarget{Contract: Web3qBridgeContract, Method: receiveFromEthFunc},
+		Args:   []string{"log.txHash", "log.index"},
+	})
+	diff = diffConfig(old, cfg)
+	if len(diff.removedRoutes) != 0 || len(diff.addedRoutes) != 1 || diff.addedRoutes[0].Name != "eth-to-w3q" {
+		t.Fatalf("unexpected diff after adding a route %+v", diff)
+	}
+
+	// a changed rpc rebuilds the chain relayer and the routes touching the chain
+	cfg = load()
+	cfg.Chain(Web3qChainName).httpRpcs = []string{"http://127.0.0.1:18545"}
+	diff = diffConfig(old, cfg)
+	if len(diff.removedChains) != 1 || diff.removedChains[0] != 3333 || len(diff.addedChains) != 1 {
+		t.Fatalf("unexpected chain diff after changing rpc %+v", diff)
+	}
+	if len(diff.removedRoutes) != 1 || len(diff.addedRoutes) != 1 {
+		t.Fatalf("unexpected route diff after changing rpc %+v", diff)
+	}
+
+	// a removed chain drops its routes
+	cfg = load()
+	cfg.Chains = cfg.Chains[1:]
+	cfg.Contracts = []*ContractConfig{cfg.contract(LightClientContract), cfg.contract(EthereumBridgeContract)}
+	cfg.Routes = nil
+	diff = diffConfig(old, cfg)
+	if len(diff.removedChains) != 1 || len(diff.addedChains) != 0 || len(diff.removedRoutes) != 1 || len(diff.addedRoutes) != 0 {
+		t.Fatalf("unexpected diff after removing a chain %+v", diff)
+	}
+}
+
+type testRegistry map[uint64]IChainRelayer
+
+func (r testRegistry) GetRelayer(chainId uint64) IChainRelayer {
+	return r[chainId]
+}
+
+func (r testRegistry) SendTaskToRelayer(task IMonitorTask) error {
+	return nil
+}
+
+func TestTaskManagerAddRemoveRoute(t *testing.T) {
+	cfg, err := LoadConfig("../config.example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	contracts, err := NewContractsConfig(cfg.Contracts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage := NewMemoryStorage()
+	registry := testRegistry{5: &EthChainRelayer{store: NewChainStore(storage, 5, 0)}, 3333: &EthChainRelayer{store: NewChainStore(storage, 3333, 0)}}
+	manager := NewTaskManager(context.Background(), registry, contracts)
+
+	for _, name := range []string{"a", "b"} {
+		route, err := NewRoute(&RouteConfig{
+			Name:   name,
+			Source: RouteSource{Contract: EthereumBridgeContract, Event: ETHEventSendTokenName},
+			Target: RouteTarget{Contract: Web3qBridgeContract, Method: receiveFromEthFunc},
+			Args:   []string{"log.txHash", "log.index"},
+		}, contracts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = manager.AddRoute(route); err != nil {
+			t.Fatal(err)
+		}
+		if err = manager.AddRoute(route); err == nil {
+			t.Fatalf("route [%s] added twice", name)
+		}
+	}
+	if len(manager.monitorQueue) != 2 || len(manager.txQueue) != 2 {
+		t.Fatalf("unexpected task pool with %d monitor and %d submit tasks", len(manager.monitorQueue), len(manager.txQueue))
+	}
+
+	if err = manager.RemoveRoute("a"); err != nil {
+		t.Fatal(err)
+	}
+	if len(manager.monitorQueue) != 1 || len(manager.txQueue) != 1 {
+		t.Fatalf("unexpected task pool with %d monitor and %d submit tasks", len(manager.monitorQueue), len(manager.txQueue))
+	}
+	routes := manager.Routes()
+	sort.Strings(routes)
+	if len(routes) != 1 || routes[0] != "b" {
+		t.Fatalf("unexpected routes %v", routes)
+	}
+}
+
+// stubRelayer is a chain relayer that routes cannot be built on
+type stubRelayer uint64
+
+func (r stubRelayer) ChainId() uint64                         { return uint64(r) }
+func (r stubRelayer) SendMonitorTask(task IMonitorTask) error { return nil }
+func (r stubRelayer) Start() error                            { return nil }
+func (r stubRelayer) Stop() error                             { return nil }
+func (r stubRelayer) Close() error                            { return nil }
+
+func TestReloadRollback(t *testing.T) {
+	cfg, err := LoadConfig("../config.example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := *cfg
+	old.Routes = nil
+	contracts, err := NewContractsConfig(cfg.Contracts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage := NewMemoryStorage()
+	c := NewCoordinator(&CoordinatorOptions{Contracts: contracts, Storage: storage})
+	defer c.Stop()
+	c.AddChainRelayer(&EthChainRelayer{ChainConfig: cfg.ChainById(5), store: NewChainStore(storage, 5, 0)})
+	c.AddChainRelayer(stubRelayer(3333))
+	c.cfg = &old
+
+	// the route from chain 3333 fails after the route from chain 5 is added, which is removed again
+	cfg.Routes = append([]*RouteConfig{{
+		Name:   "eth-to-w3q",
+		Source: RouteSource{Contract: EthereumBridgeContract, Event: ETHEventSendTokenName},
+		Target: RouteTarget{Contract: Web3qBridgeContract, Method: receiveFromEthFunc},
+		Args:   []string{"log.txHash", "log.index"},
+	}}, cfg.Routes...)
+	if err = c.Reload(cfg); err == nil {
+		t.Fatal("reloaded a route on a relayer it cannot be built on")
+	} else if !strings.Contains(err.Error(), "w3q-to-eth") {
+		t.Fatalf("unexpected reload error %v", err)
+	}
+	if routes := c.taskManager.Routes(); len(routes) != 0 {
+		t.Fatalf("routes %v left by the failed reload", routes)
+	}
+	if c.cfg != &old {
+		t.Fatal("the config of the failed reload is kept")
+	}
+}
@@ -0,0 +1,791 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RouteConfig declares a relay pipeline: every `event` emitted by the source contract is turned into a call of
+// `method` at the target contract, with the arguments given by args. The chains are the ones the contracts are
+// deployed at. With headerRelay set, the source header of the event is submitted to the light client first.
+type RouteConfig struct {
+	Name        string            `json:"name"`
+	Source      RouteSource       `json:"source"`
+	HeaderRelay *RouteHeaderRelay `json:"headerRelay,omitempty"`
+	Target      RouteTarget       `json:"target"`
+	Args        []string          `json:"args"`
+}
+
+// RouteSource is the event relayed by a route. StartBlock is where the first run of the route backfills from,
+// later runs backfill from the saved checkpoint. Without startBlock the first run relays the events from the head on.
+type RouteSource struct {
+	Contract   string `json:"contract"`
+	Event      string `json:"event"`
+	StartBlock uint64 `json:"startBlock,omitempty"`
+}
+
+type RouteTarget struct {
+	Contract string `json:"contract"`
+	Method   string `json:"method"`
+}
+
+// RouteHeaderRelay is the light client at the target chain that the source headers are submitted to, the methods
+// default to the ones of LightClientContract
+type RouteHeaderRelay struct {
+	Contract     string `json:"contract"`
+	SubmitMethod string `json:"submitMethod,omitempty"`
+	ExistMethod  string `json:"existMethod,omitempty"`
+	EpochMethod  string `json:"epochMethod,omitempty"`
+}
+
+func (r *RouteConfig) validate(contracts map[string]uint64) error {
+	if r.Name == "" {
+		return errors.New("route name is empty")
+	}
+	if strings.Contains(r.Name, "/") {
+		return fmt.Errorf("route name [%s] contains '/'", r.Name)
+	}
+
+	sourceChainId, ok := contracts[r.Source.Contract]
+	if !ok {
+		return fmt.Errorf("route [%s] with unknown source contract [%s]", r.Name, r.Source.Contract)
+	}
+	if r.Source.Event == "" {
+		return fmt.Errorf("route [%s] with empty source event", r.Name)
+	}
+
+	targetChainId, ok := contracts[r.Target.Contract]
+	if !ok {
+		return fmt.Errorf("route [%s] with unknown target contract [%s]", r.Name, r.Target.Contract)
+	}
+	if r.Target.Method == "" {
+		return fmt.Errorf("route [%s] with empty target method", r.Name)
+	}
+
+	if r.HeaderRelay != nil {
+		chainId, ok := contracts[r.HeaderRelay.Contract]
+		if !ok {
+			return fmt.Errorf("route [%s] with unknown headerRelay contract [%s]", r.Name, r.HeaderRelay.Contract)
+		}
+		if chainId != targetChainId {
+			return fmt.Errorf("route [%s] headerRelay contract [%s] no deployed at the target chain [%d]", r.Name, r.HeaderRelay.Contract, targetChainId)
+		}
+		if sourceChainId == targetChainId {
+			return fmt.Errorf("route [%s] relays headers to the chain [%d] they come from", r.Name, sourceChainId)
+		}
+	}
+	return nil
+}
+
+// routeArg produces one argument of the target method from the source event log and its decoded fields
+type routeArg func(source *EthChainRelayer, l *types.Log, fields map[string]interface{}) (interface{}, error)
+
+// Route is a RouteConfig resolved against the contracts config, ready to be built into tasks by the TaskManager
+type Route struct {
+	*RouteConfig
+
+	source *ContractDetail
+	target *ContractDetail
+	header *ContractDetail
+	event  abi.Event
+
+	args         []routeArg
+	decodeFields bool
+
+	// store is the storage namespace of the source chain, set when the route is added to the TaskManager
+	store *ChainStore
+}
+
+func NewRoute(conf *RouteConfig, contracts ContractsConfig) (*Route, error) {
+	r := &Route{RouteConfig: conf}
+
+	if r.source = contracts.GetContract(conf.Source.Contract); r.source == nil {
+		return nil, fmt.Errorf("route [%s] source contract [%s] no exist at contracts config", conf.Name, conf.Source.Contract)
+	}
+	if r.target = contracts.GetContract(conf.Target.Contract); r.target == nil {
+		return nil, fmt.Errorf("route [%s] target contract [%s] no exist at contracts config", conf.Name, conf.Target.Contract)
+	}
+
+	event, ok := r.source.Abi.Events[conf.Source.Event]
+	if !ok {
+		return nil, fmt.Errorf("route [%s] event [%s] no exist at the abi of contract [%s]", conf.Name, conf.Source.Event, r.source.Name)
+	}
+	r.event = event
+
+	method, ok := r.target.Abi.Methods[conf.Target.Method]
+	if !ok {
+		return nil, fmt.Errorf("route [%s] method [%s] no exist at the abi of contract [%s]", conf.Name, conf.Target.Method, r.target.Name)
+	}
+	if len(conf.Args) != len(method.Inputs) {
+		return nil, fmt.Errorf("route [%s] maps %d args but method [%s] takes %d", conf.Name, len(conf.Args), method.Name, len(method.Inputs))
+	}
+
+	for _, arg := range conf.Args {
+		ra, err := r.parseArg(arg)
+		if err != nil {
+			return nil, fmt.Errorf("route [%s] arg [%s]: %w", conf.Name, arg, err)
+		}
+		r.args = append(r.args, ra)
+	}
+
+	if conf.HeaderRelay != nil {
+		if r.header = contracts.GetContract(conf.HeaderRelay.Contract); r.header == nil {
+			return nil, fmt.Errorf("route [%s] headerRelay contract [%s] no exist at contracts config", conf.Name, conf.HeaderRelay.Contract)
+		}
+		err := contracts.CheckAbi(r.header.Name, []string{r.SubmitHeaderMethod(), r.HeaderExistMethod(), r.NextEpochMethod()}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("route [%s]: %w", conf.Name, err)
+		}
+	}
+
+	return r, nil
+}
+
+// parseArg supports log.blockNumber, log.index, log.txHash, log.address, log.data, log.topic<N>, event.<field>,
+// receiptProof and the literals uint:<n> and bool:<b>
+func (r *Route) parseArg(arg string) (routeArg, error) {
+	switch arg {
+	case "log.blockNumber":
+		return func(_ *EthChainRelayer, l *types.Log, _ map[string]interface{}) (interface{}, error) {
+			return new(big.Int).SetUint64(l.BlockNumber), nil
+		}, nil
+	case "log.index":
+		return func(_ *EthChainRelayer, l *types.Log, _ map[string]interface{}) (interface{}, error) {
+			return new(big.Int).SetUint64(uint64(l.Index)), nil
+		}, nil
+	case "log.txHash":
+		return func(_ *EthChainRelayer, l *types.Log, _ map[string]interface{}) (interface{}, error) {
+			return l.TxHash, nil
+		}, nil
+	case "log.address":
+		return func(_ *EthChainRelayer, l *types.Log, _ map[string]interface{}) (interface{}, error) {
+			return l.Address, nil
+		}, nil
+	case "log.data":
+		return func(_ *EthChainRelayer, l *types.Log, _ map[string]interface{}) (interface{}, error) {
+			return l.Data, nil
+		}, nil
+	case "receiptProof":
+		return func(source *EthChainRelayer, l *types.Log, _ map[string]interface{}) (interface{}, error) {
+			proof, err := source.GetReceiptProof(l.TxHash)
+			if err != nil {
+				return nil, err
+			}
+			return &Proof{Value: proof.ReceiptValue, ProofPath: proof.ReceiptPath, HpKey: proof.ReceiptKey}, nil
+		}, nil
+	}
+
+	switch {
+	case strings.HasPrefix(arg, "log.topic"):
+		n, err := strconv.Atoi(strings.TrimPrefix(arg, "log.topic"))
+		if err != nil || n < 0 || n > 3 {
+			return nil, errors.New("topic index should be 0-3")
+		}
+		return func(_ *EthChainRelayer, l *types.Log, _ map[string]interface{}) (interface{}, error) {
+			if n >= len(l.Topics) {
+				return nil, fmt.Errorf("log with %d topics has no topic%d", len(l.Topics), n)
+			}
+			return l.Topics[n], nil
+		}, nil
+
+	case strings.HasPrefix(arg, "event."):
+		name := strings.TrimPrefix(arg, "event.")
+		found := false
+		for _, input := range r.event.Inputs {
+			found = found || input.Name == name
+		}
+		if !found {
+			return nil, fmt.Errorf("event [%s] has no field [%s]", r.event.Name, name)
+		}
+		r.decodeFields = true
+		return func(_ *EthChainRelayer, _ *types.Log, fields map[string]interface{}) (interface{}, error) {
+			return fields[name], nil
+		}, nil
+
+	case strings.HasPrefix(arg, "uint:"):
+		v, ok := new(big.Int).SetString(strings.TrimPrefix(arg, "uint:"), 0)
+		if !ok || v.Sign() < 0 {
+			return nil, errors.New("invalid uint literal")
+		}
+		return func(_ *EthChainRelayer, _ *types.Log, _ map[string]interface{}) (interface{}, error) {
+			return v, nil
+		}, nil
+
+	case strings.HasPrefix(arg, "bool:"):
+		v, err := strconv.ParseBool(strings.TrimPrefix(arg, "bool:"))
+		if err != nil {
+			return nil, errors.New("invalid bool literal")
+		}
+		return func(_ *EthChainRelayer, _ *types.Log, _ map[string]interface{}) (interface{}, error) {
+			return v, nil
+		}, nil
+	}
+
+	return nil, errors.New("unknown arg")
+}
+
+// TargetArgs produces the arguments of the target method for the event log l emitted at the source chain
+func (r *Route) TargetArgs(source *EthChainRelayer, l *types.Log) ([]interface{}, error) {
+	var fields map[string]interface{}
+	if r.decodeFields {
+		fields = make(map[string]interface{})
+		if err := r.source.Abi.UnpackIntoMap(fields, r.event.Name, l.Data); err != nil {
+			return nil, err
+		}
+		var indexed abi.Arguments
+		for _, input := range r.event.Inputs {
+			if input.Indexed {
+				indexed = append(indexed, input)
+			}
+		}
+		if len(l.Topics) == 0 {
+			return nil, errors.New("log without topics")
+		}
+		if err := abi.ParseTopicsIntoMap(fields, indexed, l.Topics[1:]); err != nil {
+			return nil, err
+		}
+	}
+
+	args := make([]interface{}, 0, len(r.args))
+	for i, ra := range r.args {
+		arg, err := ra(source, l, fields)
+		if err != nil {
+			return nil, fmt.Errorf("failed to produce arg [%s]: %w", r.RouteConfig.Args[i], err)
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+func (r *Route) submit(source, target *EthChainRelayer, task *SubmitTxTask, l *types.Log) (*types.Transaction, error) {
+	args, err := r.TargetArgs(source, l)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := target.GenTx(task, args...)
+	if err != nil {
+		return tx, err
+	}
+	signedTx, err := target.SubmitTx(tx)
+	if err != nil {
+		return tx, err
+	}
+	return signedTx, nil
+}
+
+// observe persists the log as a job of the route, logs observed before are dropped. A log that reappears in another
+// block is reverted at its former block first, which cancels the pending target tx of the job.
+func (r *Route) observe(target *EthChainRelayer, l *types.Log) bool {
+	job, err := r.store.Jobs.Get(r.Name, l.TxHash, l.Index)
+	if err != nil {
+		log.Error("Route::observe() failed to get job", "route", r.Name, "txHash", l.TxHash, "logIndex", l.Index, "err", err.Error())
+	} else if job != nil && job.Stage != JobReverted && job.Log.BlockHash != l.BlockHash {
+		r.revert(target, job.Log)
+	}
+
+	isNew, err := r.store.ObserveLog(r.Name, r.source.Addr, r.event.ID, l)
+	if err != nil {
+		log.Error("Route::observe() failed to persist job", "route", r.Name, "txHash", l.TxHash, "logIndex", l.Index, "err", err.Error())
+		return true
+	}
+	if !isNew {
+		log.Info("Route::observe() drop the log observed before", "route", r.Name, "txHash", l.TxHash, "logIndex", l.Index)
+	}
+	return isNew
+}
+
+// errLogReverted is returned for the logs dropped because a reorg removed them from the source chain
+var errLogReverted = errors.New("log removed by a reorg")
+
+// revert moves the job of the log removed by a reorg to JobReverted and cancels the latest target tx of the job if it
+// is still pending, the former ones were replaced by it. The job starts over if the log reappears in another block.
+func (r *Route) revert(target *EthChainRelayer, l *types.Log) {
+	job, err := r.store.Jobs.Revert(r.Name, l)
+	if err != nil {
+		log.Error("Route::revert() failed to revert job", "route", r.Name, "txHash", l.TxHash, "logIndex", l.Index, "err", err.Error())
+		return
+	}
+	if job == nil {
+		return
+	}
+	log.Warn("Route::revert() job reverted by a reorg", "route", r.Name, "txHash", l.TxHash, "logIndex", l.Index, "block", l.BlockNumber, "blockHash", l.BlockHash)
+	if target == nil || len(job.SubmittedTxs) == 0 {
+		return
+	}
+	txHash := job.SubmittedTxs[len(job.SubmittedTxs)-1]
+	if _, err = target.CancelTx(txHash); err != nil {
+		log.Error("Route::revert() failed to cancel the pending tx", "route", r.Name, "txHash", l.TxHash, "logIndex", l.Index, "targetTx", txHash, "err", err.Error())
+	}
+}
+
+// reverted reports whether the log was removed from the source chain by a reorg: its job was reverted or observed
+// again in another block, or its block is no longer canonical. The last catches the reorgs the monitor missed.
+func (r *Route) reverted(source *EthChainRelayer, l *types.Log) (bool, error) {
+	job, err := r.store.Jobs.Get(r.Name, l.TxHash, l.Index)
+	if err != nil {
+		return false, err
+	}
+	if job != nil && (job.Stage == JobReverted || job.Log.BlockHash != l.BlockHash) {
+		return true, nil
+	}
+
+	canonical, err := source.IsCanonical(l.BlockNumber, l.BlockHash)
+	if err != nil || canonical {
+		return false, err
+	}
+	if _, err = r.store.Jobs.Revert(r.Name, l); err != nil {
+		return true, err
+	}
+	log.Warn("Route::reverted() job reverted as its block is no longer canonical", "route", r.Name, "txHash", l.TxHash, "logIndex", l.Index, "block", l.BlockNumber, "blockHash", l.BlockHash)
+	return true, nil
+}
+
+// confirm waits for the receipt of the target tx of the log and finishes the job once the tx succeeded. A tx that
+// fails or is dropped is recorded as the error of the job, and the log is sent to resubmit for a new tx unless the
+// job has RetryTimes txs submitted already. It gives up on a job reverted or resubmitted meanwhile.
+func (r *Route) confirm(ctx context.Context, target *EthChainRelayer, l *types.Log, txHash common.Hash, resubmit chan<- interface{}) {
+	receipt, err := target.WaitMined(ctx, txHash)
+	if err != nil {
+		return
+	}
+	if receipt != nil && receipt.Status == types.ReceiptStatusSuccessful {
+		if err = r.store.Jobs.Update(r.Name, l, JobConfirmed, common.Hash{}); err != nil && err != errJobReverted {
+			log.Error("Route::confirm() failed to update job", "route", r.Name, "txHash", l.TxHash, "logIndex", l.Index, "err", err.Error())
+			return
+		}
+		log.Info("Route::confirm() target tx succeeded", "route", r.Name, "txHash", l.TxHash, "logIndex", l.Index, "targetTx", txHash, "block", receipt.BlockNumber)
+		return
+	}
+
+	job, err := r.store.Jobs.Get(r.Name, l.TxHash, l.Index)
+	if err != nil {
+		log.Error("Route::confirm() failed to get job", "route", r.Name, "txHash", l.TxHash, "logIndex", l.Index, "err", err.Error())
+		return
+	}
+	if job == nil || job.Stage != JobSubmitted || job.Log.BlockHash != l.BlockHash || job.SubmittedTxs[len(job.SubmittedTxs)-1] != txHash {
+		return
+	}
+	txErr := fmt.Errorf("target tx %s dropped", txHash.Hex())
+	if receipt != nil {
+		txErr = fmt.Errorf("target tx %s failed in block %d", txHash.Hex(), receipt.BlockNumber)
+	}
+	if err = r.store.Jobs.Fail(r.Name, l, txErr); err != nil {
+		log.Error("Route::confirm() failed to record job error", "route", r.Name, "txHash", l.TxHash, "logIndex", l.Index, "err", err.Error())
+	}
+	if len(job.SubmittedTxs) >= RetryTimes {
+		log.Error("Route::confirm() give up the job after its target txs failed", "route", r.Name, "txHash", l.TxHash, "logIndex", l.Index, "targetTx", txHash, "err", txErr.Error())
+		return
+	}
+
+	log.Warn("Route::confirm() resubmit the log of the failed target tx", "route", r.Name, "txHash", l.TxHash, "logIndex", l.Index, "targetTx", txHash, "err", txErr.Error())
+	select {
+	case resubmit <- l:
+	case <-ctx.Done():
+	}
+}
+
+func (r *Route) SourceChainId() uint64 {
+	return r.source.ChainId
+}
+
+func (r *Route) TargetChainId() uint64 {
+	return r.target.ChainId
+}
+
+func (r *Route) SubmitHeaderMethod() string {
+	if r.HeaderRelay.SubmitMethod != "" {
+		return r.HeaderRelay.SubmitMethod
+	}
+	return SubmitHeaderFunc
+}
+
+func (r *Route) HeaderExistMethod() string {
+	if r.HeaderRelay.ExistMethod != "" {
+		return r.HeaderRelay.ExistMethod
+	}
+	return BlockExistFunc
+}
+
+func (r *Route) NextEpochMethod() string {
+	if r.HeaderRelay.EpochMethod != "" {
+		return r.HeaderRelay.EpochMethod
+	}
+	return GetNextEpochHeightFunc
+}
+
+// routeTasks are the tasks built for a route, kept to stop them when the route is removed
+type routeTasks struct {
+	route     *Route
+	monitors  []IMonitorTask
+	schedules []*ScheduleTask
+	txs       []*SubmitTxTask
+
+	// unfinished jobs are fed into the channel of their stage once the tasks are started
+	unfinished []*Job
+	observedCh chan interface{}
+	verifiedCh chan interface{} // the observedCh, unless the logs are gated by the quorum of the source chain
+	scheduleCh chan interface{}
+	done       chan struct{}
+
+	source *EthChainRelayer
+	target *EthChainRelayer
+	quorum *QuorumVerifier
+}
+
+// start runs the quorum gate of the route if any, the finality gate of a route without headerRelay, and resumes the
+// unfinished jobs
+func (rt *routeTasks) start(ctx context.Context) {
+	if rt.quorum != nil {
+		go rt.verifying(ctx)
+	}
+	if rt.route.HeaderRelay == nil {
+		go rt.finalizing(ctx)
+	}
+	rt.resume(ctx)
+}
+
+// context returns a context of pctx that is also done once the route is removed
+func (rt *routeTasks) context(pctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(pctx)
+	go func() {
+		select {
+		case <-rt.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// finalizing sends the verified logs on to the submit-tx task once their block is final by the finality policy of
+// the source chain. Every log waits on its own, so the logs behind it are not held up by its wait. The logs of routes
+// with headerRelay wait in the schedule task instead.
+func (rt *routeTasks) finalizing(pctx context.Context) {
+	ctx, cancel := rt.context(pctx)
+	defer cancel()
+
+	for {
+		select {
+		case data := <-rt.verifiedCh:
+			go func(l *types.Log) {
+				if err := rt.source.WaitFinalized(ctx, l.BlockNumber); err != nil {
+					return
+				}
+				select {
+				case rt.scheduleCh <- l:
+				case <-ctx.Done():
+				}
+			}(data.(*types.Log))
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// verifying sends the observed logs on once the quorum of the source chain confirms them. Up to QuorumConcurrency
+// logs are verified at once, so that a log the providers are slow to return holds up no other log. The logs it fails
+// to confirm are held at JobObserved with the error recorded, and verified again every recheckInterval of the quorum.
+func (rt *routeTasks) verifying(pctx context.Context) {
+	ctx, cancel := rt.context(pctx)
+	defer cancel()
+
+	var (
+		lock sync.Mutex
+		held []*types.Log
+	)
+	slots := make(chan struct{}, QuorumConcurrency)
+	recheck := time.NewTicker(rt.quorum.recheckInterval)
+	defer recheck.Stop()
+
+	var retry []*types.Log
+	for {
+		var l *types.Log
+		if len(retry) != 0 {
+			l, retry = retry[0], retry[1:]
+			if !rt.held(l) {
+				continue
+			}
+		} else {
+			select {
+			case data := <-rt.observedCh:
+				l = data.(*types.Log)
+			case <-recheck.C:
+				lock.Lock()
+				retry, held = held, nil
+				lock.Unlock()
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		go func(l *types.Log) {
+			defer func() { <-slots }()
+			if !rt.verify(ctx, l) {
+				lock.Lock()
+				held = append(held, l)
+				lock.Unlock()
+			}
+		}(l)
+	}
+}
+
+// held reports whether the job of the log still waits for its quorum, the job may have been reverted or observed
+// again in another block since its verification failed
+func (rt *routeTasks) held(l *types.Log) bool {
+	job, err := rt.route.store.Jobs.Get(rt.route.Name, l.TxHash, l.Index)
+	if err != nil {
+		log.Error("routeTasks::verifying() failed to get job", "route", rt.route.Name, "txHash", l.TxHash, "logIndex", l.Index, "err", err.Error())
+		return false
+	}
+	return job != nil && job.Stage == JobObserved && job.Log.BlockHash == l.BlockHash
+}
+
+// verify confirms the log with the quorum and sends it on, it returns false if the quorum fails to confirm the log
+func (rt *routeTasks) verify(ctx context.Context, l *types.Log) bool {
+	res, err := rt.quorum.Verify(ctx, l)
+	if err != nil {
+		// stopped while verifying
+		return true
+	}
+	if len(res.Disagreements) != 0 || !res.Verified() {
+		rt.quorum.Alert(rt.route.Name, l, res)
+	}
+	if err = rt.route.store.Jobs.RecordQuorum(rt.route.Name, l, res); err == errJobReverted {
+		log.Warn("routeTasks::verifying() drop the log removed by a reorg", "route", rt.route.Name, "txHash", l.TxHash, "logIndex", l.Index)
+		return true
+	} else if err != nil {
+		log.Error("routeTasks::verifying() failed to record quorum", "route", rt.route.Name, "txHash", l.TxHash, "logIndex", l.Index, "err", err.Error())
+	}
+	if !res.Verified() {
+		log.Error("routeTasks::verifying() hold the log not confirmed by quorum", "route", rt.route.Name, "txHash", l.TxHash, "logIndex", l.Index, "confirmations", res.Confirmations, "threshold", res.Threshold)
+		return false
+	}
+
+	log.Info("routeTasks::verifying() log confirmed by quorum", "route", rt.route.Name, "txHash", l.TxHash, "logIndex", l.Index, "confirmations", res.Confirmations)
+	select {
+	case rt.verifiedCh <- l:
+	case <-ctx.Done():
+	}
+	return true
+}
+
+// resume sends the unfinished jobs of the route on from the stage they stopped at
+func (rt *routeTasks) resume(ctx context.Context) {
+	jobs := rt.unfinished
+	rt.unfinished = nil
+	if len(jobs) == 0 {
+		return
+	}
+
+	log.Info("routeTasks::resume() resume unfinished jobs", "route", rt.route.Name, "jobs", len(jobs))
+	go func() {
+		for _, job := range jobs {
+			ch := rt.observedCh
+			switch job.Stage {
+			case JobVerified:
+				ch = rt.verifiedCh
+			case JobScheduled:
+				ch = rt.scheduleCh
+			case JobSubmitted:
+				// the receipt of the latest tx is checked again, the ones before were replaced by it
+				if n := len(job.SubmittedTxs); n != 0 && rt.target != nil {
+					go rt.route.confirm(ctx, rt.target, job.Log, job.SubmittedTxs[n-1], rt.scheduleCh)
+					continue
+				}
+				ch = rt.scheduleCh
+			}
+			select {
+			case ch <- job.Log:
+			case <-rt.done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// AddRoute builds the tasks of the route: the monitor of the source event feeding the submit-tx task of the target
+// method, and for routes with headerRelay the header monitor and the submit-header task gated by a schedule task.
+// The tasks are started at once if the TaskManager is running.
+func (manager *TaskManager) AddRoute(r *Route) error {
+	rt, err := manager.addRoute(r)
+	if err != nil || rt == nil {
+		return err
+	}
+
+	log.Info("TaskManager::AddRoute() start the tasks of route", "route", r.Name)
+	if err = manager.startTasks(rt.monitors, rt.schedules, rt.txs); err != nil {
+		if rerr := manager.RemoveRoute(r.Name); rerr != nil {
+			log.Warn("TaskManager::AddRoute() failed to remove the route failing to start", "route", r.Name, "err", rerr.Error())
+		}
+		return err
+	}
+	rt.start(manager.ctx)
+	return nil
+}
+
+// addRoute builds the tasks of the route into the task pool, it returns the route tasks if the TaskManager has
+// started already and they are to be started by the caller
+func (manager *TaskManager) addRoute(r *Route) (*routeTasks, error) {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+
+	if _, ok := manager.routes[r.Name]; ok {
+		return nil, fmt.Errorf("route [%s] already exists", r.Name)
+	}
+	if manager.started && manager.Status() != TaskManagerDoing {
+		return nil, fmt.Errorf("route [%s] added to a stopped TaskManager", r.Name)
+	}
+	if manager.registry.GetRelayer(r.SourceChainId()) == nil {
+		return nil, fmt.Errorf("route [%s] source chainRelayer %d no exist", r.Name, r.SourceChainId())
+	}
+	if manager.registry.GetRelayer(r.TargetChainId()) == nil {
+		return nil, fmt.Errorf("route [%s] target chainRelayer %d no exist", r.Name, r.TargetChainId())
+	}
+
+	source, ok := manager.registry.GetRelayer(r.SourceChainId()).(*EthChainRelayer)
+	if !ok {
+		return nil, fmt.Errorf("route [%s] source chainRelayer %d is not an EthChainRelayer", r.Name, r.SourceChainId())
+	}
+	// the target is nil for other chain relayers, the submit-tx task fails on it then
+	target, _ := manager.registry.GetRelayer(r.TargetChainId()).(*EthChainRelayer)
+	r.store = source.Store()
+	unfinished, err := r.store.Jobs.Unfinished(r.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	monitorEventTask, err := manager.GenMonitorEventTask(r.SourceChainId(), r.source.Addr, r.event.Name)
+	if err != nil {
+		return nil, err
+	}
+	monitorEventTask.filter = func(l *types.Log) bool {
+		return r.observe(target, l)
+	}
+	monitorEventTask.revert = func(l *types.Log) {
+		r.revert(target, l)
+	}
+	monitorEventTask.route = r.Name
+	monitorEventTask.startBlock = r.Source.StartBlock
+	submitTask := manager.GenRoute_SubmitTxTask(r)
+	rt := &routeTasks{route: r, unfinished: unfinished, scheduleCh: submitTask.receiveCh, done: make(chan struct{}), source: source, target: target, quorum: source.Quorum()}
+
+	if r.HeaderRelay == nil {
+		rt.monitors = []IMonitorTask{monitorEventTask}
+		rt.txs = []*SubmitTxTask{submitTask}
+		rt.verifiedCh = make(chan interface{})
+	} else {
+		stask, err := NewRouteScheduleTask(manager, r)
+		if err != nil {
+			return nil, err
+		}
+		monitorHeaderTask := manager.GenSubHeaderMonitorTask(r.SourceChainId())
+		submitHeaderTask := manager.GenSubmitHeader_SubmitTxTask(r)
+
+		if err = monitorHeaderTask.SubscribeData(stask.receiveHeader); err != nil {
+			return nil, err
+		}
+		if err = stask.SubscribeHeader(submitHeaderTask.receiveCh); err != nil {
+			return nil, err
+		}
+		if err = stask.SubscribeBurnLog(submitTask.receiveCh); err != nil {
+			return nil, err
+		}
+		rt.monitors = []IMonitorTask{monitorEventTask, monitorHeaderTask}
+		rt.schedules = []*ScheduleTask{stask}
+		rt.txs = []*SubmitTxTask{submitHeaderTask, submitTask}
+		rt.verifiedCh = stask.receiveBurnLog
+	}
+	rt.observedCh = rt.verifiedCh
+	if rt.quorum != nil {
+		rt.observedCh = make(chan interface{})
+	}
+	if err = monitorEventTask.SubscribeData(rt.observedCh); err != nil {
+		return nil, err
+	}
+
+	for _, t := range rt.monitors {
+		manager.AddMonitorTask(t)
+	}
+	for _, t := range rt.schedules {
+		manager.AddScheduleTask(t)
+	}
+	for _, t := range rt.txs {
+		manager.AddSubmitTxTask(t)
+	}
+	manager.routes[r.Name] = rt
+
+	if !manager.started {
+		return nil, nil
+	}
+	return rt, nil
+}
+
+// RemoveRoute stops the tasks of the route and drops them from the task pool
+func (manager *TaskManager) RemoveRoute(name string) error {
+	manager.lock.Lock()
+	rt, ok := manager.routes[name]
+	if !ok {
+		manager.lock.Unlock()
+		return fmt.Errorf("route [%s] no exist", name)
+	}
+	delete(manager.routes, name)
+	close(rt.done)
+	for _, t := range rt.monitors {
+		manager.RemoveMonitorTask(t)
+	}
+	for _, t := range rt.schedules {
+		manager.RemoveScheduleTask(t)
+	}
+	for _, t := range rt.txs {
+		manager.RemoveSubmitTxTask(t)
+	}
+	manager.lock.Unlock()
+
+	log.Info("TaskManager::RemoveRoute() stop the tasks of route", "route", name)
+	for _, t := range rt.monitors {
+		if t.Status() == MonitorTaskMonitoring {
+			t.Stop()
+		}
+	}
+	for _, t := range rt.schedules {
+		if t.Status() == ScheduleTaskRunning {
+			t.Stop()
+		}
+	}
+	for _, t := range rt.txs {
+		if t.Status() == SubmitTxTaskDoing {
+			t.Stop()
+		}
+	}
+	return nil
+}
+
+// Routes returns the names of the routes the TaskManager relays
+func (manager *TaskManager) Routes() []string {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+	names := make([]string, 0, len(manager.routes))
+	for name := range manager.routes {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,338 @@
+package v2
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewRoute(t *testing.T) {
+	cfg, err := LoadConfig("../config.example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	contracts, err := NewContractsConfig(cfg.Contracts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, conf := range cfg.Routes {
+		if _, err = NewRoute(conf, contracts); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	invalid := map[string]*RouteConfig{
+		"unknown event": {Name: "r", Source: RouteSource{Contract: Web3qBridgeContract, Event: "Foo"}, Target: RouteTarget{Contract: Web3qBridgeContract, Method: receiveFromEthFunc}, Args: []string{"log.txHash", "log.index"}},
+		"args count":    {Name: "r", Source: RouteSource{Contract: EthereumBridgeContract, Event: ETHEventSendTokenName}, Target: RouteTarget{Contract: Web3qBridgeContract, Method: receiveFromEthFunc}, Args: []string{"log.txHash"}},
+		"unknown arg":   {Name: "r", Source: RouteSource{Contract: EthereumBridgeContract, Event: ETHEventSendTokenName}, Target: RouteTarget{Contract: Web3qBridgeContract, Method: receiveFromEthFunc}, Args: []string{"log.txHash", "log.foo"}},
+		"unknown field": {Name: "r", Source: RouteSource{Contract: EthereumBridgeContract, Event: ETHEventSendTokenName}, Target: RouteTarget{Contract: Web3qBridgeContract, Method: receiveFromEthFunc}, Args: []string{"log.txHash", "event.nonce"}},
+	}
+	for name, conf := range invalid {
+		if _, err = NewRoute(conf, contracts); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestRouteTargetArgs(t *testing.T) {
+	cfg, err := LoadConfig("../config.example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	contracts, err := NewContractsConfig(cfg.Contracts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := &RouteConfig{
+		Name:   "eth-to-w3q",
+		Source: RouteSource{Contract: Web3qBridgeContract, Event: ETHEventSendTokenName},
+		Target: RouteTarget{Contract: Web3qBridgeContract, Method: receiveFromEthFunc},
+		Args:   []string{"log.txHash", "event.amount"},
+	}
+	r, err := NewRoute(conf, contracts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := contracts.GetContractAbi(Web3qBridgeContract).Events[ETHEventSendTokenName]
+	data, err := event.Inputs.NonIndexed().Pack(big.NewInt(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &types.Log{
+		TxHash: common.HexToHash("0x01"),
+		Topics: []common.Hash{event.ID, common.BigToHash(big.NewInt(7)), common.BytesToHash(common.HexToAddress("0x02").Bytes())},
+		Data:   data,
+	}
+
+	args, err := r.TargetArgs(nil, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args[0].(common.Hash) != l.TxHash {
+		t.Errorf("unexpected txHash arg %v", args[0])
+	}
+	if args[1].(*big.Int).Cmp(big.NewInt(100)) != 0 {
+		t.Errorf("unexpected amount arg %v", args[1])
+	}
+
+	if _, err = contracts.GetContractAbi(Web3qBridgeContract).Pack(receiveFromEthFunc, args...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRouteReverted(t *testing.T) {
+	f := newFakeEth(3333)
+	l := types.Log{TxHash: common.HexToHash("0x01"), Topics: []common.Hash{}, Data: []byte{}}
+	f.mine(l)
+	source := newPollingRelayer(t, f)
+	store := NewChainStore(NewMemoryStorage(), f.chainId, 0)
+	r := &Route{RouteConfig: &RouteConfig{Name: "w3q-to-eth"}, store: store}
+
+	l = f.logs[0]
+	if _, err := store.Jobs.Observe(r.Name, &l); err != nil {
+		t.Fatal(err)
+	}
+	if reverted, err := r.reverted(source, &l); err != nil || reverted {
+		t.Fatalf("canonical log reverted %v err %v", reverted, err)
+	}
+
+	// a log whose block was replaced is reverted though its removal was never seen
+	orphan := l
+	orphan.TxHash, orphan.BlockHash = common.HexToHash("0x02"), common.HexToHash("0xb1")
+	if _, err := store.Jobs.Observe(r.Name, &orphan); err != nil {
+		t.Fatal(err)
+	}
+	if reverted, err := r.reverted(source, &orphan); err != nil || !reverted {
+		t.Fatalf("orphaned log reverted %v err %v", reverted, err)
+	}
+	if job, err := store.Jobs.Get(r.Name, orphan.TxHash, orphan.Index); err != nil || job.Stage != JobReverted {
+		t.Fatalf("unexpected orphaned job %+v err %v", job, err)
+	}
+
+	// a log observed again in another block drops the submission of its former block
+	moved := l
+	moved.BlockHash = common.HexToHash("0xb2")
+	if _, err := store.Jobs.Observe(r.Name, &moved); err != nil {
+		t.Fatal(err)
+	}
+	if reverted, err := r.reverted(source, &l); err != nil || !reverted {
+		t.Fatalf("superseded log reverted %v err %v", reverted, err)
+	}
+}
+
+func TestRouteObserveMoved(t *testing.T) {
+	f := newFakeEth(3333)
+	l := types.Log{TxHash: common.HexToHash("0x01"), Topics: []common.Hash{}, Data: []byte{}}
+	f.mine(l)
+	target := newPollingRelayer(t, f)
+	store := NewChainStore(NewMemoryStorage(), f.chainId, 0)
+	r := &Route{RouteConfig: &RouteConfig{Name: "w3q-to-eth"}, source: &ContractDetail{}, store: store}
+
+	l = f.logs[0]
+	if !r.observe(target, &l) {
+		t.Fatal("new log dropped")
+	}
+	replaced, pending := common.HexToHash("0xa1"), common.HexToHash("0xa2")
+	for _, txHash := range []common.Hash{replaced, pending} {
+		if err := store.Jobs.Update(r.Name, &l, JobSubmitted, txHash); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a log reappearing in another block cancels the latest tx of its former block only
+	moved := l
+	moved.BlockHash = common.HexToHash("0xb2")
+	if !r.observe(target, &moved) {
+		t.Fatal("moved log dropped")
+	}
+	f.lock.Lock()
+	lookups := f.lookups
+	f.lock.Unlock()
+	if len(lookups) != 1 || lookups[0] != pending {
+		t.Fatalf("cancelled txs %v, want %s", lookups, pending.Hex())
+	}
+	if job, err := store.Jobs.Get(r.Name, l.TxHash, l.Index); err != nil || job.Stage != JobObserved || job.Log.BlockHash != moved.BlockHash {
+		t.Fatalf("unexpected moved job %+v err %v", job, err)
+	}
+	if r.observe(target, &moved) {
+		t.Fatal("log observed twice")
+	}
+}
+
+func TestRouteQuorumGate(t *testing.T) {
+	a := types.Log{TxHash: common.HexToHash("0x01"), Topics: []common.Hash{}, Data: []byte{}}
+	b := types.Log{TxHash: common.HexToHash("0x02"), Topics: []common.Hash{}, Data: []byte{}}
+	synced, lagging := newFakeEth(5), newFakeEth(5)
+	synced.mine(a)
+	synced.mine(b)
+	lagging.mine(a)
+
+	conf := &QuorumConfig{Rpcs: []string{serveFakeEth(t, synced).URL, serveFakeEth(t, lagging).URL}, Threshold: 2, Timeout: "100ms"}
+	v := NewQuorumVerifier(5, conf)
+	v.retryInterval, v.recheckInterval = 10*time.Millisecond, 50*time.Millisecond
+	defer v.Close()
+
+	store := NewChainStore(NewMemoryStorage(), 5, 0)
+	r := &Route{RouteConfig: &RouteConfig{Name: "eth-to-w3q"}, store: store}
+	logA, logB := synced.logs[0], synced.logs[1]
+	for _, l := range []*types.Log{&logA, &logB} {
+		if _, err := store.Jobs.Observe(r.Name, l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rt := &routeTasks{route: r, observedCh: make(chan interface{}), verifiedCh: make(chan interface{}, 2), done: make(chan struct{}), quorum: v}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go rt.verifying(ctx)
+
+	// the log the lagging provider lacks holds up no other log
+	rt.observedCh <- &logB
+	rt.observedCh <- &logA
+	select {
+	case l := <-rt.verifiedCh:
+		if l.(*types.Log).TxHash != a.TxHash {
+			t.Fatalf("log %s verified before the lagging provider has it", l.(*types.Log).TxHash.Hex())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("log held up by another log")
+	}
+
+	// the held log is verified again once the lagging provider catches up
+	time.Sleep(150 * time.Millisecond)
+	if job, err := store.Jobs.Get(r.Name, b.TxHash, 0); err != nil || job.Stage != JobObserved || job.LastError == "" {
+		t.Fatalf("unexpected held job %+v err %v", job, err)
+	}
+	lagging.mine(b)
+	select {
+	case l := <-rt.verifiedCh:
+		if l.(*types.Log).TxHash != b.TxHash {
+			t.Fatalf("unexpected verified log %s", l.(*types.Log).TxHash.Hex())
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("held log not verified again")
+	}
+}
+
+func TestRouteFinalityGate(t *testing.T) {
+	f := newFakeEth(5)
+	f.mine(types.Log{TxHash: common.HexToHash("0x01"), Topics: []common.Hash{}, Data: []byte{}})
+	f.mine(types.Log{TxHash: common.HexToHash("0x02"), Topics: []common.Hash{}, Data: []byte{}})
+	source := newPollingRelayer(t, f)
+	source.ChainConfig.finality = &FinalityConfig{Policy: ConfirmationsFinality, Confirmations: 1}
+	source.heads.Update(f.headers[2])
+
+	r := &Route{RouteConfig: &RouteConfig{Name: "eth-to-w3q"}}
+	rt := &routeTasks{route: r, verifiedCh: make(chan interface{}), scheduleCh: make(chan interface{}), done: make(chan struct{}), source: source}
+	go rt.finalizing(context.Background())
+	defer close(rt.done)
+
+	// the log of block 2 waiting for its confirmation holds up no log of a final block
+	a, b := f.logs[0], f.logs[1]
+	rt.verifiedCh <- &b
+	rt.verifiedCh <- &a
+	recv := func(want common.Hash) {
+		t.Helper()
+		select {
+		case l := <-rt.scheduleCh:
+			if l.(*types.Log).TxHash != want {
+				t.Fatalf("got log %s, want %s", l.(*types.Log).TxHash.Hex(), want.Hex())
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("log %s not sent on", want.Hex())
+		}
+	}
+	recv(a.TxHash)
+	source.heads.Update(f.mine())
+	recv(b.TxHash)
+}
+
+func TestSubmitTxTaskStop(t *testing.T) {
+	relayer := newPollingRelayer(t, newFakeEth(5))
+	var wg sync.WaitGroup
+	task := NewSubmitTxTask(context.Background(), common.Address{}, "contract", "method", 5, 5, testRegistry{5: relayer}, &wg)
+	busy := make(chan struct{})
+	task.submitTxFunc = func(source *EthChainRelayer, target *EthChainRelayer, value interface{}, task *SubmitTxTask) (*types.Transaction, error) {
+		close(busy)
+		<-task.ctx.Done()
+		return nil, task.ctx.Err()
+	}
+	stopped := make(chan error)
+	go func() { stopped <- task.Start() }()
+	task.receiveCh <- struct{}{}
+	<-busy
+
+	// a task busy with a tx is stopped without waiting for it
+	done := make(chan error)
+	go func() { done <- task.Stop() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Stop blocked by the busy task")
+	}
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("task not stopped")
+	}
+	if task.Status() != SubmitTxTaskStopped {
+		t.Fatalf("unexpected status %d", task.Status())
+	}
+}
+
+func TestRouteConfirm(t *testing.T) {
+	f := newFakeEth(5)
+	f.mine(types.Log{TxHash: common.HexToHash("0xa1"), Topics: []common.Hash{}, Data: []byte{}})
+	target := newPollingRelayer(t, f)
+	store := NewChainStore(NewMemoryStorage(), 3333, 0)
+	r := &Route{RouteConfig: &RouteConfig{Name: "w3q-to-eth"}, store: store}
+	ctx := context.Background()
+	resubmit := make(chan interface{}, 1)
+
+	submit := func(l *types.Log, txHash common.Hash) {
+		t.Helper()
+		if err := store.Jobs.Update(r.Name, l, JobSubmitted, txHash); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expect := func(l *types.Log, stage JobStage, resubmitted bool) {
+		t.Helper()
+		job, err := store.Jobs.Get(r.Name, l.TxHash, l.Index)
+		if err != nil || job.Stage != stage {
+			t.Fatalf("unexpected job %+v err %v", job, err)
+		}
+		if got := len(resubmit) != 0; got != resubmitted {
+			t.Fatalf("resubmitted %v, want %v", got, resubmitted)
+		}
+		if resubmitted {
+			<-resubmit
+		}
+	}
+
+	// a succeeded tx finishes the job
+	mined := &types.Log{TxHash: common.HexToHash("0x01"), BlockHash: common.HexToHash("0xb1"), Topics: []common.Hash{}, Data: []byte{}}
+	submit(mined, common.HexToHash("0xa1"))
+	r.confirm(ctx, target, mined, common.HexToHash("0xa1"), resubmit)
+	expect(mined, JobConfirmed, false)
+
+	// a dropped tx is submitted again until the job has RetryTimes txs
+	dropped := &types.Log{TxHash: common.HexToHash("0x02"), BlockHash: common.HexToHash("0xb1"), Topics: []common.Hash{}, Data: []byte{}}
+	for i := 1; i <= RetryTimes; i++ {
+		txHash := common.BigToHash(big.NewInt(int64(0xc0 + i)))
+		submit(dropped, txHash)
+		r.confirm(ctx, target, dropped, txHash, resubmit)
+		expect(dropped, JobSubmitted, i < RetryTimes)
+	}
+	if job, err := store.Jobs.Get(r.Name, dropped.TxHash, dropped.Index); err != nil || job.LastError == "" || job.Finished() {
+		t.Fatalf("unexpected given up job %+v err %v", job, err)
+	}
+}
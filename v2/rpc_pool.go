@@ -0,0 +1,402 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	"sync"
+	"time"
+)
+
+// MaxEndpointHeadLag is the number of blocks an endpoint may fall behind the highest head of the pool and still
+// be taken as healthy
+const MaxEndpointHeadLag = 3
+
+// MaxEndpointFailures is the number of consecutive failed probes or calls that take an endpoint down, so that a
+// transient failure neither fails over nor redials the connection serving the subscriptions
+const MaxEndpointFailures = 3
+
+var errWrongChainId = errors.New("wrong chainId")
+
+type endpoint struct {
+	url      string
+	client   *ethclient.Client
+	rpc      *rpc.Client // the rpc client under client
+	head     uint64
+	latency  time.Duration
+	errRate  float64 // moving average of the failed probes and calls, from 0 to 1
+	failures int     // the consecutive failed probes and calls
+	down     bool    // MaxEndpointFailures probes or calls failed in a row, or the connection was given up by Redial
+	limiter  *RateLimiter
+
+	// probeLock serializes the probes, so that a down endpoint is redialed once
+	probeLock sync.Mutex
+}
+
+// score is lower for healthier endpoints
+func (e *endpoint) score() float64 {
+	return float64(e.latency) * (1 + 4*e.errRate)
+}
+
+// EndpointPool is the IChainClient of a chain served by several rpc endpoints. Every endpoint is probed for its head
+// and latency each interval, calls go to the endpoint that is up, not lagging behind the others by more than
+// MaxEndpointHeadLag blocks, and has the lowest latency weighted by its error rate.
+type EndpointPool struct {
+	chainId   uint64
+	endpoints []*endpoint // http and ws share the endpoint of a url
+	http      []*endpoint
+	ws        []*endpoint
+	bestHttp  *endpoint
+	bestWs    *endpoint
+	interval  time.Duration
+
+	// lock guards the fields of the endpoints and the best ones
+	lock   sync.RWMutex
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewEndpointPool dials every endpoint, if only one kind of urls is given they serve both the ws-client and the
+// http-client. Unreachable endpoints are redialed by the probes, an endpoint serving another chain is an error.
+// Each endpoint is limited by its own RateLimiter of limits, which may be nil.
+func NewEndpointPool(pctx context.Context, chainId uint64, httpUrls, wsUrls []string, interval time.Duration, limits *RateLimitConfig) (*EndpointPool, error) {
+	if len(httpUrls) == 0 && len(wsUrls) == 0 {
+		return nil, errors.New("httpUrls and wsUrls are empty")
+	}
+
+	ctx, cancel := context.WithCancel(pctx)
+	p := &EndpointPool{chainId: chainId, interval: interval, ctx: ctx, cancel: cancel}
+	add := func(url string) *endpoint {
+		for _, e := range p.endpoints {
+			if e.url == url {
+				return e
+			}
+		}
+		e := &endpoint{url: url, limiter: NewRateLimiter(limits)}
+		p.endpoints = append(p.endpoints, e)
+		return e
+	}
+	for _, url := range httpUrls {
+		p.http = append(p.http, add(url))
+	}
+	for _, url := range wsUrls {
+		p.ws = append(p.ws, add(url))
+	}
+	if len(p.http) == 0 {
+		p.http = p.ws
+	}
+	if len(p.ws) == 0 {
+		p.ws = p.http
+	}
+
+	for _, e := range p.endpoints {
+		if err := p.probe(e); err != nil {
+			if errors.Is(err, errWrongChainId) {
+				p.Close()
+				return nil, fmt.Errorf("endpoint %s: %w", e.url, err)
+			}
+			log.Warn("EndpointPool::NewEndpointPool() endpoint unreachable", "chainId", chainId, "url", e.url, "err", err.Error())
+		}
+	}
+	p.selectBest()
+	if p.bestHttp == nil || p.bestWs == nil {
+		p.Close()
+		return nil, fmt.Errorf("no reachable endpoint of chain %d", chainId)
+	}
+
+	go p.probing()
+	return p, nil
+}
+
+func (p *EndpointPool) dial(ctx context.Context, url string) (*ethclient.Client, *rpc.Client, error) {
+	rpcClient, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, nil, err
+	}
+	client := ethclient.NewClient(rpcClient)
+	chainId, err := client.ChainID(ctx)
+	if err != nil {
+		client.Close()
+		return nil, nil, err
+	}
+	if chainId.Uint64() != p.chainId {
+		client.Close()
+		return nil, nil, fmt.Errorf("%w %d, expect %d", errWrongChainId, chainId.Uint64(), p.chainId)
+	}
+	return client, rpcClient, nil
+}
+
+// probe redials the endpoint if it is down and measures its head and latency. An endpoint out of its daily quota is
+// left alone until the quota resets.
+func (p *EndpointPool) probe(e *endpoint) error {
+	if e.limiter.Exhausted() {
+		return errQuotaExhausted
+	}
+
+	timeout := p.interval
+	if timeout > 10*time.Second {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(p.ctx, timeout)
+	defer cancel()
+
+	e.probeLock.Lock()
+	defer e.probeLock.Unlock()
+	p.lock.RLock()
+	client, down := e.client, e.down
+	p.lock.RUnlock()
+
+	if client == nil || down {
+		if err := e.limiter.Wait(ctx, PriorityLive, 1); err != nil {
+			return err
+		}
+		newClient, newRpc, err := p.dial(ctx, e.url)
+		if err != nil {
+			p.record(e, 0, 0, err)
+			return err
+		}
+		p.lock.Lock()
+		stale := e.client
+		e.client, e.rpc, client = newClient, newRpc, newClient
+		p.lock.Unlock()
+		if stale != nil {
+			stale.Close()
+		}
+	}
+
+	if err := e.limiter.Wait(ctx, PriorityLive, 1); err != nil {
+		return err
+	}
+	start := time.Now()
+	head, err := client.BlockNumber(ctx)
+	p.record(e, head, time.Since(start), err)
+	return err
+}
+
+func (p *EndpointPool) record(e *endpoint, head uint64, latency time.Duration, err error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	e.count(err)
+	if err == nil {
+		e.head, e.latency = head, latency
+	}
+}
+
+// count records the outcome of a probe or call, the caller holds the lock of the pool. An endpoint goes down after
+// MaxEndpointFailures consecutive failures and is up again after a success.
+func (e *endpoint) count(err error) {
+	failed := 0.0
+	if err != nil {
+		failed = 1
+		e.failures++
+	} else {
+		e.failures = 0
+	}
+	e.errRate = 0.8*e.errRate + 0.2*failed
+	if err == nil {
+		e.down = false
+	} else if e.failures >= MaxEndpointFailures {
+		e.down = true
+	}
+}
+
+// callFailed reports whether the error of a call is a failure of the endpoint, rather than an answer of the node
+func callFailed(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, ethereum.NotFound) {
+		return false
+	}
+	var rpcErr rpc.Error
+	return !errors.As(err, &rpcErr)
+}
+
+// Report records the outcome of a call made with client, an endpoint failing MaxEndpointFailures calls in a row is
+// taken down and failed over at once, without waiting for the next probe
+func (p *EndpointPool) Report(client *ethclient.Client, err error) {
+	if err != nil && !callFailed(err) {
+		return
+	}
+
+	p.lock.Lock()
+	var failed *endpoint
+	for _, e := range p.endpoints {
+		if e.client == client {
+			down := e.down
+			e.count(err)
+			if e.down && !down {
+				failed = e
+			}
+			break
+		}
+	}
+	p.lock.Unlock()
+
+	if failed != nil {
+		log.Warn("EndpointPool::Report() endpoint down after failed calls", "chainId", p.chainId, "url", failed.url, "err", err.Error())
+		p.selectBest()
+	}
+}
+
+func (p *EndpointPool) probing() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, e := range p.endpoints {
+				if err := p.probe(e); err != nil && p.ctx.Err() == nil {
+					log.Warn("EndpointPool::probing() endpoint probe failed", "chainId", p.chainId, "url", e.url, "err", err.Error())
+				}
+			}
+			p.selectBest()
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+// selectBest picks the healthiest http and ws endpoints with quota left, the current ones are kept if no endpoint is up
+func (p *EndpointPool) selectBest() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	maxHead := uint64(0)
+	for _, e := range p.endpoints {
+		if e.client != nil && !e.down && e.head > maxHead {
+			maxHead = e.head
+		}
+	}
+
+	pick := func(endpoints []*endpoint, current *endpoint, kind string) *endpoint {
+		var best *endpoint
+		for _, e := range endpoints {
+			if e.client == nil || e.down || e.head+MaxEndpointHeadLag < maxHead || e.limiter.Exhausted() {
+				continue
+			}
+			if best == nil || e.score() < best.score() {
+				best = e
+			}
+		}
+		if best == nil {
+			return current
+		}
+		if current != nil && best != current {
+			log.Info("EndpointPool::selectBest() switch endpoint", "chainId", p.chainId, "kind", kind, "from", current.url, "to", best.url, "head", best.head, "latency", best.latency)
+		}
+		return best
+	}
+	p.bestHttp = pick(p.http, p.bestHttp, "http")
+	p.bestWs = pick(p.ws, p.bestWs, "ws")
+}
+
+func (p *EndpointPool) ChainId() uint64 {
+	return p.chainId
+}
+
+func (p *EndpointPool) WsClient() *ethclient.Client {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.bestWs.client
+}
+
+func (p *EndpointPool) HttpClient() *ethclient.Client {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.bestHttp.client
+}
+
+// Limit waits for the rate limit of the endpoint serving client to let calls requests of the priority through
+func (p *EndpointPool) Limit(ctx context.Context, client *ethclient.Client, priority Priority, calls int) error {
+	p.lock.RLock()
+	var limiter *RateLimiter
+	for _, e := range p.endpoints {
+		if e.client == client {
+			limiter = e.limiter
+			break
+		}
+	}
+	p.lock.RUnlock()
+
+	if limiter == nil {
+		// the client has been replaced by a redial, the call fails on the closed client anyway
+		return nil
+	}
+	return limiter.Wait(ctx, priority, calls)
+}
+
+func (p *EndpointPool) RpcClient(client *ethclient.Client) *rpc.Client {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	for _, e := range p.endpoints {
+		if e.client == client {
+			return e.rpc
+		}
+	}
+	return nil
+}
+
+// EndpointUsage is the state and the request counters of an endpoint
+type EndpointUsage struct {
+	Url      string `json:"url"`
+	Head     uint64 `json:"head"`
+	Down     bool   `json:"down"`
+	BestWs   bool   `json:"bestWs"`
+	BestHttp bool   `json:"bestHttp"`
+	*RateUsage
+}
+
+// Usage returns the usage of the endpoints in the order they are configured
+func (p *EndpointPool) Usage() []*EndpointUsage {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	usage := make([]*EndpointUsage, 0, len(p.endpoints))
+	for _, e := range p.endpoints {
+		usage = append(usage, &EndpointUsage{Url: e.url, Head: e.head, Down: e.down, BestWs: e == p.bestWs, BestHttp: e == p.bestHttp, RateUsage: e.limiter.Usage()})
+	}
+	return usage
+}
+
+// Redial takes the endpoint of stale as down, redials it and fails over to the healthiest endpoint. It fails if no
+// ws endpoint is up afterwards.
+func (p *EndpointPool) Redial(ctx context.Context, stale *ethclient.Client) error {
+	p.lock.Lock()
+	var dropped *endpoint
+	for _, e := range p.ws {
+		if e.client == stale && !e.down {
+			dropped = e
+			e.down = true
+			break
+		}
+	}
+	p.lock.Unlock()
+
+	if dropped != nil {
+		if err := p.probe(dropped); err != nil {
+			log.Warn("EndpointPool::Redial() failed to redial endpoint", "chainId", p.chainId, "url", dropped.url, "err", err.Error())
+		}
+	}
+	p.selectBest()
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	if p.bestWs.down {
+		return fmt.Errorf("no ws endpoint of chain %d is up", p.chainId)
+	}
+	return nil
+}
+
+func (p *EndpointPool) Close() {
+	p.cancel()
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, e := range p.endpoints {
+		if e.client != nil {
+			e.client.Close()
+		}
+	}
+}
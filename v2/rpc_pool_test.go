@@ -0,0 +1,108 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/rpc"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// serveFakeEth serves the fake chain over http
+func serveFakeEth(t *testing.T, f *fakeEth) *httptest.Server {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", f); err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(server)
+	t.Cleanup(func() {
+		ts.Close()
+		server.Stop()
+	})
+	return ts
+}
+
+func TestEndpointPoolFailover(t *testing.T) {
+	lagging, synced := newFakeEth(5), newFakeEth(5)
+	for i := 0; i < 10; i++ {
+		synced.mine()
+	}
+	laggingServer, syncedServer := serveFakeEth(t, lagging), serveFakeEth(t, synced)
+
+	pool, err := NewEndpointPool(context.Background(), 5, []string{laggingServer.URL, syncedServer.URL}, nil, time.Hour, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	if pool.bestHttp.url != syncedServer.URL || pool.bestWs.url != syncedServer.URL {
+		t.Fatalf("the lagging endpoint is chosen: http %s ws %s", pool.bestHttp.url, pool.bestWs.url)
+	}
+
+	// the synced endpoint goes away, the pool fails over once the dropped client is redialed
+	syncedServer.Close()
+	if err = pool.Redial(context.Background(), pool.WsClient()); err != nil {
+		t.Fatal(err)
+	}
+	if pool.bestWs.url != laggingServer.URL {
+		t.Fatalf("no failover to %s, ws %s", laggingServer.URL, pool.bestWs.url)
+	}
+	if head, err := pool.WsClient().BlockNumber(context.Background()); err != nil || head != 0 {
+		t.Fatalf("unexpected head %d err %v", head, err)
+	}
+
+	laggingServer.Close()
+	if err = pool.Redial(context.Background(), pool.WsClient()); err == nil {
+		t.Fatal("redial succeeded without any endpoint up")
+	}
+}
+
+func TestEndpointPoolConsecutiveFailures(t *testing.T) {
+	firstServer, secondServer := serveFakeEth(t, newFakeEth(5)), serveFakeEth(t, newFakeEth(5))
+
+	pool, err := NewEndpointPool(context.Background(), 5, []string{firstServer.URL, secondServer.URL}, nil, time.Hour, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	best := pool.bestHttp
+	client := pool.HttpClient()
+
+	// answers of the node and isolated failures keep the endpoint and its client
+	pool.Report(client, ethereum.NotFound)
+	for i := 0; i < MaxEndpointFailures-1; i++ {
+		pool.Report(client, errors.New("connection reset"))
+	}
+	if err = pool.probe(best); err != nil {
+		t.Fatal(err)
+	}
+	pool.Report(client, errors.New("connection reset"))
+	if best.down || best.client != client || pool.HttpClient() != client {
+		t.Fatal("endpoint taken down by isolated failures")
+	}
+
+	// calls failing in a row take it down and fail over at once
+	for i := 0; i < MaxEndpointFailures-1; i++ {
+		pool.Report(client, errors.New("connection reset"))
+	}
+	if !best.down || pool.bestHttp == best {
+		t.Fatalf("no failover after %d failed calls, best %s", MaxEndpointFailures, pool.bestHttp.url)
+	}
+
+	// the next probe redials it
+	if err = pool.probe(best); err != nil {
+		t.Fatal(err)
+	}
+	if best.down || best.client == client {
+		t.Fatal("down endpoint not redialed")
+	}
+}
+
+func TestEndpointPoolRejectsOtherChain(t *testing.T) {
+	server := serveFakeEth(t, newFakeEth(3333))
+	_, err := NewEndpointPool(context.Background(), 5, []string{server.URL}, nil, time.Hour, nil)
+	if !errors.Is(err, errWrongChainId) {
+		t.Fatalf("endpoint of another chain accepted: err %v", err)
+	}
+}
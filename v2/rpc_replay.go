@@ -0,0 +1,442 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gorilla/websocket"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// RpcFixture is the JSON-RPC traffic of an endpoint captured by an RpcRecorder and served by an RpcReplayServer
+type RpcFixture struct {
+	Calls         []*RecordedCall         `json:"calls"`
+	Subscriptions []*RecordedSubscription `json:"subscriptions,omitempty"`
+}
+
+// RecordedCall is a request and the result or error the endpoint answered it with
+type RecordedCall struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *RecordedError  `json:"error,omitempty"`
+}
+
+type RecordedError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *RecordedError) Error() string {
+	return e.Message
+}
+
+// RecordedSubscription is an eth_subscribe request and the notifications the endpoint pushed for it, in order
+type RecordedSubscription struct {
+	Namespace     string            `json:"namespace"`
+	Params        json.RawMessage   `json:"params"`
+	Notifications []json.RawMessage `json:"notifications"`
+}
+
+func LoadRpcFixture(path string) (*RpcFixture, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f := new(RpcFixture)
+	if err = json.Unmarshal(b, f); err != nil {
+		return nil, fmt.Errorf("rpc fixture [%s]: %w", path, err)
+	}
+	return f, nil
+}
+
+// Save writes the fixture as indented json, so that a re-recorded fixture diffs line by line
+func (f *RpcFixture) Save(path string) error {
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(b, '\n'), 0644)
+}
+
+// rpcKey identifies the calls a recorded answer is replayed for, the params are compacted so that the encoding
+// of the client does not matter
+func rpcKey(method string, params json.RawMessage) string {
+	var buf bytes.Buffer
+	if len(params) == 0 || json.Compact(&buf, params) != nil || buf.String() == "null" {
+		buf.Reset()
+		buf.WriteString("[]")
+	}
+	return method + " " + buf.String()
+}
+
+type rpcMessage struct {
+	Version string          `json:"jsonrpc,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Error   *RecordedError  `json:"error,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+}
+
+const (
+	rpcErrMethodNotFound = -32601
+	rpcErrInvalidParams  = -32602
+	rpcErrInternal       = -32603
+	rpcErrServer         = -32000
+)
+
+func rpcResponse(req *rpcMessage, result json.RawMessage, rerr *RecordedError) *rpcMessage {
+	return &rpcMessage{Version: "2.0", ID: req.ID, Result: result, Error: rerr}
+}
+
+var errNotificationsUnsupported = &RecordedError{Code: rpcErrMethodNotFound, Message: "notifications not supported"}
+
+// rpcConn is a client connection of the recorder or the replay server, ws is nil for http requests
+type rpcConn struct {
+	ctx  context.Context
+	ws   *websocket.Conn
+	lock sync.Mutex
+	subs map[string]func()
+}
+
+func (c *rpcConn) write(v interface{}) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.ws.WriteJSON(v)
+}
+
+func (c *rpcConn) notify(namespace, id string, result json.RawMessage) error {
+	return c.write(&rpcMessage{
+		Version: "2.0",
+		Method:  namespace + "_subscription",
+		Params:  json.RawMessage(fmt.Sprintf(`{"subscription":%q,"result":%s}`, id, result)),
+	})
+}
+
+func (c *rpcConn) addSub(id string, cancel func()) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.subs[id] = cancel
+}
+
+// unsubscribe answers <namespace>_unsubscribe, telling whether the subscription existed
+func (c *rpcConn) unsubscribe(req *rpcMessage) *rpcMessage {
+	var params []string
+	if err := json.Unmarshal(req.Params, &params); err != nil || len(params) != 1 {
+		return rpcResponse(req, nil, &RecordedError{Code: rpcErrInvalidParams, Message: "invalid subscription id"})
+	}
+	c.lock.Lock()
+	cancel, ok := c.subs[params[0]]
+	delete(c.subs, params[0])
+	c.lock.Unlock()
+	if ok {
+		cancel()
+	}
+	return rpcResponse(req, json.RawMessage(fmt.Sprint(ok)), nil)
+}
+
+func (c *rpcConn) close() {
+	c.lock.Lock()
+	subs := c.subs
+	c.subs = make(map[string]func())
+	c.lock.Unlock()
+	for _, cancel := range subs {
+		cancel()
+	}
+}
+
+// rpcHandlerFunc answers a request. A subscription returns start too, which pushes its notifications once the
+// response has been written.
+type rpcHandlerFunc func(conn *rpcConn, req *rpcMessage) (resp *rpcMessage, start func())
+
+var wsUpgrader = websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+
+// serveRpc serves single and batch requests over http, and over ws if the request upgrades the connection
+func serveRpc(w http.ResponseWriter, r *http.Request, handle rpcHandlerFunc) {
+	if !websocket.IsWebSocketUpgrade(r) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		conn := &rpcConn{ctx: r.Context(), subs: make(map[string]func())}
+		resp, _ := handleRpcMessage(conn, body, handle)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(resp)
+		return
+	}
+
+	ws, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Warn("serveRpc() failed to upgrade the ws connection", "err", err.Error())
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	conn := &rpcConn{ctx: ctx, ws: ws, subs: make(map[string]func())}
+	defer func() {
+		cancel()
+		conn.close()
+		ws.Close()
+	}()
+	for {
+		_, body, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		resp, starts := handleRpcMessage(conn, body, handle)
+		conn.lock.Lock()
+		err = ws.WriteMessage(websocket.TextMessage, resp)
+		conn.lock.Unlock()
+		if err != nil {
+			return
+		}
+		for _, start := range starts {
+			start()
+		}
+	}
+}
+
+func handleRpcMessage(conn *rpcConn, body []byte, handle rpcHandlerFunc) ([]byte, []func()) {
+	var starts []func()
+	answer := func(req *rpcMessage) *rpcMessage {
+		switch {
+		case strings.HasSuffix(req.Method, "_subscribe") && conn.ws == nil:
+			return rpcResponse(req, nil, errNotificationsUnsupported)
+		case strings.HasSuffix(req.Method, "_unsubscribe"):
+			return conn.unsubscribe(req)
+		}
+		resp, start := handle(conn, req)
+		if start != nil {
+			starts = append(starts, start)
+		}
+		return resp
+	}
+
+	var resp interface{}
+	if body = bytes.TrimSpace(body); len(body) > 0 && body[0] == '[' {
+		var reqs []*rpcMessage
+		if err := json.Unmarshal(body, &reqs); err != nil {
+			resp = rpcResponse(&rpcMessage{ID: json.RawMessage("null")}, nil, &RecordedError{Code: rpcErrInvalidParams, Message: err.Error()})
+		} else {
+			resps := make([]*rpcMessage, len(reqs))
+			for i, req := range reqs {
+				resps[i] = answer(req)
+			}
+			resp = resps
+		}
+	} else {
+		req := new(rpcMessage)
+		if err := json.Unmarshal(body, req); err != nil {
+			resp = rpcResponse(&rpcMessage{ID: json.RawMessage("null")}, nil, &RecordedError{Code: rpcErrInvalidParams, Message: err.Error()})
+		} else {
+			resp = answer(req)
+		}
+	}
+	b, _ := json.Marshal(resp)
+	return b, starts
+}
+
+// RpcRecorder is a JSON-RPC proxy in front of an upstream endpoint that records the calls, subscriptions and
+// notifications passing through it. It serves http and ws, subscriptions need a ws upstream.
+type RpcRecorder struct {
+	upstream *rpc.Client
+
+	lock    sync.Mutex
+	fixture *RpcFixture
+	subId   uint64
+}
+
+func NewRpcRecorder(ctx context.Context, upstreamUrl string) (*RpcRecorder, error) {
+	upstream, err := rpc.DialContext(ctx, upstreamUrl)
+	if err != nil {
+		return nil, err
+	}
+	return &RpcRecorder{upstream: upstream, fixture: &RpcFixture{Calls: make([]*RecordedCall, 0)}}, nil
+}
+
+func (r *RpcRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	serveRpc(w, req, r.handle)
+}
+
+func (r *RpcRecorder) handle(conn *rpcConn, req *rpcMessage) (*rpcMessage, func()) {
+	var params []json.RawMessage
+	if len(req.Params) != 0 {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return rpcResponse(req, nil, &RecordedError{Code: rpcErrInvalidParams, Message: "params must be an array"}), nil
+		}
+	}
+	args := make([]interface{}, len(params))
+	for i, p := range params {
+		args[i] = p
+	}
+	if strings.HasSuffix(req.Method, "_subscribe") {
+		return r.subscribe(conn, req, args)
+	}
+
+	var result json.RawMessage
+	err := r.upstream.CallContext(conn.ctx, &result, req.Method, args...)
+	var rerr *RecordedError
+	if err != nil {
+		var callErr rpc.Error
+		if !errors.As(err, &callErr) {
+			// transport failures are not the answer of the endpoint, they are passed on without being recorded
+			return rpcResponse(req, nil, &RecordedError{Code: rpcErrInternal, Message: err.Error()}), nil
+		}
+		rerr = &RecordedError{Code: callErr.ErrorCode(), Message: callErr.Error()}
+		var dataErr rpc.DataError
+		if errors.As(err, &dataErr) {
+			rerr.Data = dataErr.ErrorData()
+		}
+		result = nil
+	}
+
+	r.lock.Lock()
+	r.fixture.Calls = append(r.fixture.Calls, &RecordedCall{Method: req.Method, Params: req.Params, Result: result, Error: rerr})
+	r.lock.Unlock()
+	return rpcResponse(req, result, rerr), nil
+}
+
+func (r *RpcRecorder) subscribe(conn *rpcConn, req *rpcMessage, args []interface{}) (*rpcMessage, func()) {
+	namespace := strings.TrimSuffix(req.Method, "_subscribe")
+	ch := make(chan json.RawMessage)
+	sub, err := r.upstream.Subscribe(conn.ctx, namespace, ch, args...)
+	if err != nil {
+		return rpcResponse(req, nil, &RecordedError{Code: rpcErrServer, Message: err.Error()}), nil
+	}
+
+	recorded := &RecordedSubscription{Namespace: namespace, Params: req.Params, Notifications: make([]json.RawMessage, 0)}
+	r.lock.Lock()
+	r.subId++
+	id := hexutil.EncodeUint64(r.subId)
+	r.fixture.Subscriptions = append(r.fixture.Subscriptions, recorded)
+	r.lock.Unlock()
+	conn.addSub(id, sub.Unsubscribe)
+
+	start := func() {
+		go func() {
+			defer sub.Unsubscribe()
+			for {
+				select {
+				case n := <-ch:
+					r.lock.Lock()
+					recorded.Notifications = append(recorded.Notifications, n)
+					r.lock.Unlock()
+					if err := conn.notify(namespace, id, n); err != nil {
+						return
+					}
+				case <-sub.Err():
+					return
+				case <-conn.ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	return rpcResponse(req, json.RawMessage(fmt.Sprintf("%q", id)), nil), start
+}
+
+// Fixture returns a copy of the traffic recorded so far
+func (r *RpcRecorder) Fixture() *RpcFixture {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	f := &RpcFixture{Calls: append([]*RecordedCall{}, r.fixture.Calls...)}
+	for _, s := range r.fixture.Subscriptions {
+		f.Subscriptions = append(f.Subscriptions, &RecordedSubscription{Namespace: s.Namespace, Params: s.Params, Notifications: append([]json.RawMessage{}, s.Notifications...)})
+	}
+	return f
+}
+
+func (r *RpcRecorder) Save(path string) error {
+	return r.Fixture().Save(path)
+}
+
+func (r *RpcRecorder) Close() {
+	r.upstream.Close()
+}
+
+// RpcReplayServer answers the calls of a fixture without an endpoint. The answers recorded for the same method and
+// params are replayed in order, the last one for every further call. Each eth_subscribe takes the next recorded
+// subscription of its params and pushes its notifications right after the response.
+type RpcReplayServer struct {
+	lock   sync.Mutex
+	calls  map[string][]*RecordedCall
+	subs   map[string][]*RecordedSubscription
+	subId  uint64
+	missed []string
+}
+
+func NewRpcReplayServer(f *RpcFixture) *RpcReplayServer {
+	s := &RpcReplayServer{calls: make(map[string][]*RecordedCall), subs: make(map[string][]*RecordedSubscription)}
+	for _, c := range f.Calls {
+		key := rpcKey(c.Method, c.Params)
+		s.calls[key] = append(s.calls[key], c)
+	}
+	for _, sub := range f.Subscriptions {
+		key := rpcKey(sub.Namespace+"_subscribe", sub.Params)
+		s.subs[key] = append(s.subs[key], sub)
+	}
+	return s
+}
+
+func (s *RpcReplayServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	serveRpc(w, req, s.handle)
+}
+
+func (s *RpcReplayServer) handle(conn *rpcConn, req *rpcMessage) (*rpcMessage, func()) {
+	key := rpcKey(req.Method, req.Params)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if strings.HasSuffix(req.Method, "_subscribe") {
+		subs := s.subs[key]
+		if len(subs) == 0 {
+			s.missed = append(s.missed, key)
+			return rpcResponse(req, nil, &RecordedError{Code: rpcErrServer, Message: "no recorded subscription for " + key}), nil
+		}
+		sub := subs[0]
+		s.subs[key] = subs[1:]
+		s.subId++
+		id := hexutil.EncodeUint64(s.subId)
+
+		ctx, cancel := context.WithCancel(conn.ctx)
+		conn.addSub(id, cancel)
+		start := func() {
+			go func() {
+				for _, n := range sub.Notifications {
+					if ctx.Err() != nil || conn.notify(sub.Namespace, id, n) != nil {
+						return
+					}
+				}
+			}()
+		}
+		return rpcResponse(req, json.RawMessage(fmt.Sprintf("%q", id)), nil), start
+	}
+
+	calls := s.calls[key]
+	if len(calls) == 0 {
+		s.missed = append(s.missed, key)
+		return rpcResponse(req, nil, &RecordedError{Code: rpcErrServer, Message: "no recorded answer for " + key}), nil
+	}
+	call := calls[0]
+	if len(calls) > 1 {
+		s.calls[key] = calls[1:]
+	}
+	return rpcResponse(req, call.Result, call.Error), nil
+}
+
+// Missed returns the method and params of the requests the fixture had no answer for
+func (s *RpcReplayServer) Missed() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]string{}, s.missed...)
+}
@@ -0,0 +1,381 @@
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rpc"
+	"io/ioutil"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var recordFixtures = flag.Bool("record", false, "record the fixtures of the replay tests from the fake devnets")
+
+// fakeDevnet adds the head subscription and the tx methods to fakeEth, it is the upstream the fixtures are recorded from
+type fakeDevnet struct {
+	*fakeEth
+	feed  event.Feed
+	calls map[string]hexutil.Bytes // eth_call results by method selector
+	nonce uint64
+	sent  []*types.Transaction
+}
+
+type fakeCallArgs struct {
+	Data hexutil.Bytes `json:"data"`
+}
+
+func newFakeDevnet(chainId uint64) *fakeDevnet {
+	return &fakeDevnet{fakeEth: newFakeEth(chainId), calls: make(map[string]hexutil.Bytes)}
+}
+
+// mine appends an empty london block and notifies the head subscriptions
+func (d *fakeDevnet) mine() *types.Header {
+	d.lock.Lock()
+	parent := d.headers[len(d.headers)-1]
+	header := &types.Header{Number: new(big.Int).Add(parent.Number, big.NewInt(1)), ParentHash: parent.Hash(), Difficulty: big.NewInt(1), BaseFee: big.NewInt(7)}
+	d.headers = append(d.headers, header)
+	d.lock.Unlock()
+	d.feed.Send(header)
+	return header
+}
+
+func (d *fakeDevnet) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, ok := rpc.NotifierFromContext(ctx)
+	if !ok {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	ch := make(chan *types.Header, 16)
+	headSub := d.feed.Subscribe(ch)
+	go func() {
+		defer headSub.Unsubscribe()
+		for {
+			select {
+			case header := <-ch:
+				notifier.Notify(sub.ID, header)
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+func (d *fakeDevnet) Call(args fakeCallArgs, block string) hexutil.Bytes {
+	if len(args.Data) >= 4 {
+		if res, ok := d.calls[hexutil.Encode(args.Data[:4])]; ok {
+			return res
+		}
+	}
+	return common.Hash{}.Bytes()
+}
+
+func (d *fakeDevnet) EstimateGas(args fakeCallArgs) hexutil.Uint64 {
+	return 100000
+}
+
+func (d *fakeDevnet) MaxPriorityFeePerGas() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(2))
+}
+
+func (d *fakeDevnet) GetTransactionCount(addr common.Address, block string) hexutil.Uint64 {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return hexutil.Uint64(d.nonce)
+}
+
+func (d *fakeDevnet) SendRawTransaction(data hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(data); err != nil {
+		return common.Hash{}, err
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.sent = append(d.sent, tx)
+	d.nonce = tx.Nonce() + 1
+	return tx.Hash(), nil
+}
+
+// replayEndpoint serves testdata/replay/<name>.json and returns its http and ws urls. With -record it records the
+// fixture from the devnet instead, saving it when the test is done.
+func replayEndpoint(t *testing.T, name string, devnet *fakeDevnet) (string, string) {
+	path := filepath.Join("testdata", "replay", name+".json")
+	var ts *httptest.Server
+	if *recordFixtures {
+		server := rpc.NewServer()
+		if err := server.RegisterName("eth", devnet); err != nil {
+			t.Fatal(err)
+		}
+		upstream := httptest.NewServer(server.WebsocketHandler([]string{"*"}))
+		recorder, err := NewRpcRecorder(context.Background(), "ws"+strings.TrimPrefix(upstream.URL, "http"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ts = httptest.NewServer(recorder)
+		t.Cleanup(func() {
+			ts.Close()
+			recorder.Close()
+			upstream.Close()
+			server.Stop()
+		})
+		t.Cleanup(func() {
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := recorder.Save(path); err != nil {
+				t.Error(err)
+			}
+		})
+	} else {
+		fixture, err := LoadRpcFixture(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		replay := NewRpcReplayServer(fixture)
+		ts = httptest.NewServer(replay)
+		t.Cleanup(ts.Close)
+		t.Cleanup(func() {
+			if missed := replay.Missed(); len(missed) != 0 {
+				t.Errorf("%s fixture without answers for %v, re-record it with -record", name, missed)
+			}
+		})
+	}
+	return ts.URL, "ws" + strings.TrimPrefix(ts.URL, "http")
+}
+
+// testKeyHex is the fixed key of the relayer in tests, so that the signed txs match the fixtures
+const testKeyHex = "b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291"
+
+// writeTestKeystore writes a keystore of the test key encrypted with passwd
+func writeTestKeystore(t *testing.T, passwd string) string {
+	prikey, err := crypto.HexToECDSA(testKeyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := keystore.EncryptKey(&keystore.Key{Address: crypto.PubkeyToAddress(prikey.PublicKey), PrivateKey: prikey}, passwd, keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "relayer.json")
+	if err = ioutil.WriteFile(path, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReplaySubmitHeader(t *testing.T) {
+	cfg, err := LoadConfig("../config.example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	contracts, err := NewContractsConfig(cfg.Contracts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w3qConf, ethConf := cfg.Chain(Web3qChainName), cfg.Chain(EthereumChainName)
+
+	w3qNet, ethNet := newFakeDevnet(w3qConf.chainId), newFakeDevnet(ethConf.chainId)
+	lightClient := contracts.GetContractAbi(LightClientContract)
+	ethNet.calls[hexutil.Encode(lightClient.Methods[GetNextEpochHeightFunc].ID)] = common.BigToHash(big.NewInt(3)).Bytes()
+	ethNet.nonce = 7
+	ethNet.mine()
+
+	httpUrl, wsUrl := replayEndpoint(t, "web3q", w3qNet)
+	w3qConf.httpRpcs, w3qConf.wssRpcs = []string{httpUrl}, []string{wsUrl}
+	httpUrl, wsUrl = replayEndpoint(t, "ethereum", ethNet)
+	ethConf.httpRpcs, ethConf.wssRpcs = []string{httpUrl}, []string{wsUrl}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	keyfile, storage := writeTestKeystore(t, ""), NewMemoryStorage()
+	source, err := NewEthChainRelayer(ctx, keyfile, "", w3qConf, contracts, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+	target, err := NewEthChainRelayer(ctx, keyfile, "", ethConf, contracts, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Close()
+	go source.Start()
+	go target.Start()
+
+	// the heads pushed by the web3q subscription reach the header store
+	if *recordFixtures {
+		for i := 0; i < 3; i++ {
+			w3qNet.mine()
+		}
+	}
+	var header *types.Header
+	for deadline := time.Now().Add(5 * time.Second); header == nil; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("head 3 of the subscription not stored")
+		}
+		if header, err = source.store.Headers.HeaderByNumber(3); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the schedule task sends the header the light client expects next to the submit task
+	manager := NewTaskManager(ctx, testRegistry{source.ChainId(): source, target.ChainId(): target}, contracts)
+	route, err := NewRoute(cfg.Routes[0], contracts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schedule, err := NewRouteScheduleTask(manager, route)
+	if err != nil {
+		t.Fatal(err)
+	}
+	submitHeaderCh := make(chan interface{}, 1)
+	if err = schedule.SubscribeHeader(submitHeaderCh); err != nil {
+		t.Fatal(err)
+	}
+	go schedule.Start()
+	schedule.receiveHeader <- &HeaderEvent{Type: HeaderCanonical, Header: header}
+	select {
+	case v := <-submitHeaderCh:
+		if v.(*types.Header).Hash() != header.Hash() {
+			t.Fatalf("scheduled header %d, want %d", v.(*types.Header).Number, header.Number)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("header not scheduled")
+	}
+
+	submit := manager.GenSubmitHeader_SubmitTxTask(route)
+	tx, err := submit.submitTxFunc(source, target, header, submit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Nonce() != 7 || *tx.To() != route.header.Addr || tx.GasFeeCap().Int64() != 16 {
+		t.Fatalf("unexpected header tx nonce %d to %s gasFeeCap %d", tx.Nonce(), tx.To().Hex(), tx.GasFeeCap())
+	}
+	if nonce, ok, err := target.store.Nonces.Get(target.relayerAddr); err != nil || !ok || nonce != 7 {
+		t.Fatalf("stored nonce %d %v %v, want 7", nonce, ok, err)
+	}
+	if *recordFixtures && len(ethNet.sent) != 1 {
+		t.Fatalf("devnet received %d txs, want 1", len(ethNet.sent))
+	}
+}
+
+func TestRpcRecordReplay(t *testing.T) {
+	f := newFakeDevnet(5)
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", f); err != nil {
+		t.Fatal(err)
+	}
+	upstream := httptest.NewServer(server.WebsocketHandler([]string{"*"}))
+	defer upstream.Close()
+	recorder, err := NewRpcRecorder(context.Background(), "ws"+strings.TrimPrefix(upstream.URL, "http"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recorder.Close()
+	ts := httptest.NewServer(recorder)
+	defer ts.Close()
+
+	// the calls, a batch and the notifications of a subscription go through the recorder
+	run := func(url string, mine func()) ([]*types.Header, error) {
+		client, err := ethclient.Dial("ws" + strings.TrimPrefix(url, "http"))
+		if err != nil {
+			return nil, err
+		}
+		defer client.Close()
+		headCh := make(chan *types.Header)
+		sub, err := client.SubscribeNewHead(context.Background(), headCh)
+		if err != nil {
+			return nil, err
+		}
+		defer sub.Unsubscribe()
+		mine()
+		mine()
+		var heads []*types.Header
+		for len(heads) < 2 {
+			select {
+			case header := <-headCh:
+				heads = append(heads, header)
+			case err := <-sub.Err():
+				return nil, err
+			case <-time.After(time.Second):
+				t.Fatal("no head notified")
+			}
+		}
+		if _, err = client.BlockNumber(context.Background()); err != nil {
+			return nil, err
+		}
+		batch := []rpc.BatchElem{
+			{Method: "eth_getBlockByNumber", Args: []interface{}{"0x1", false}, Result: new(types.Header)},
+			{Method: "eth_sendRawTransaction", Args: []interface{}{"0x00"}, Result: new(common.Hash)},
+		}
+		rpcClient, err := rpc.Dial(url)
+		if err != nil {
+			return nil, err
+		}
+		defer rpcClient.Close()
+		if err = rpcClient.BatchCall(batch); err != nil {
+			return nil, err
+		}
+		if batch[0].Error != nil || batch[0].Result.(*types.Header).Hash() != heads[0].Hash() {
+			t.Fatalf("batched header %v %v", batch[0].Result, batch[0].Error)
+		}
+		if batch[1].Error == nil {
+			t.Fatal("expected the error of the raw tx to be answered")
+		}
+		return heads, nil
+	}
+	recorded, err := run(ts.URL, func() { f.mine() })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fixture := recorder.Fixture()
+	b, err := json.Marshal(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := new(RpcFixture)
+	if err = json.Unmarshal(b, loaded); err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Subscriptions) != 1 || len(loaded.Subscriptions[0].Notifications) != 2 {
+		t.Fatalf("unexpected recorded subscriptions %s", b)
+	}
+
+	replay := NewRpcReplayServer(loaded)
+	rs := httptest.NewServer(replay)
+	defer rs.Close()
+	replayed, err := run(rs.URL, func() {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range recorded {
+		if replayed[i].Hash() != recorded[i].Hash() {
+			t.Fatalf("replayed head %d differs", i)
+		}
+	}
+	if missed := replay.Missed(); len(missed) != 0 {
+		t.Fatalf("unexpected missed requests %v", missed)
+	}
+
+	client, err := ethclient.Dial(rs.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if _, err = client.HeaderByNumber(context.Background(), big.NewInt(9)); err == nil || len(replay.Missed()) != 1 {
+		t.Fatalf("unrecorded call answered: err %v missed %v", err, replay.Missed())
+	}
+}
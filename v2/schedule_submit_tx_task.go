@@ -4,12 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
 	"math/big"
 	"sync"
 	"sync/atomic"
-	"time"
 )
 
 const (
@@ -23,8 +23,10 @@ type ScheduleTask struct {
 	taskType uint64
 	name     string
 
-	ethRelayer *EthChainRelayer
-	w3qRelayer *EthChainRelayer
+	route *Route
+
+	sourceRelayer *EthChainRelayer
+	targetRelayer *EthChainRelayer
 
 	sourceChain uint64
 	targetChain uint64
@@ -38,71 +40,53 @@ type ScheduleTask struct {
 	sendSubmitHeaderSignal chan interface{}
 	sendReceiveTokenSignal chan interface{}
 	receiveBurnLog         chan interface{}
-	receiveHeader          chan *types.Header
+	receiveHeader          chan *HeaderEvent
 	beforeSendHeader       chan *types.Header
 	SentHeader             map[uint64]bool
 
 	status uint32
-	pwg    sync.WaitGroup
+	pwg    *sync.WaitGroup
 	ctx    context.Context
 	cf     context.CancelFunc
 }
 
-func NewScheduleTaskFromW3qToEth(name string, manager *TaskManager) (*ScheduleTask, error) {
-	ctx, cancelFunc := context.WithCancel(manager.ctx)
-
-	monitorEventTask := manager.GenMonitorEventTask(Web3qChainConf.chainId, Web3qChainConf.bridgeAddr, "SendToken")
-	monitorHeaderTask := manager.GenSubHeaderMonitorTask(Web3qChainConf.chainId)
+// NewRouteScheduleTask gates the event logs of a route with headerRelay: the source header of every log is
+// submitted to the light client at the target chain before the log is sent on
+func NewRouteScheduleTask(manager *TaskManager, r *Route) (*ScheduleTask, error) {
+	sourceRelayer, ok := manager.registry.GetRelayer(r.SourceChainId()).(*EthChainRelayer)
+	if !ok {
+		return nil, fmt.Errorf("chainRelayer %d no exist", r.SourceChainId())
+	}
+	targetRelayer, ok := manager.registry.GetRelayer(r.TargetChainId()).(*EthChainRelayer)
+	if !ok {
+		return nil, fmt.Errorf("chainRelayer %d no exist", r.TargetChainId())
+	}
 
-	recTokenTx := manager.GenReceiveToken_SubmitTxTask_OnEth()
-	submitHeaderTask := manager.GenSubmitWeb3qHeader_SubmitTxTask_OnEth()
+	ctx, cancelFunc := context.WithCancel(manager.ctx)
 
 	// add monitor latest head
 	stask := &ScheduleTask{
 		taskType: ScheduleTaskType,
-		name:     name,
+		name:     r.Name,
+		route:    r,
 
-		ethRelayer: GlobalCoordinator.GetRelayer(EthereumChainConf.chainId).(*EthChainRelayer),
-		w3qRelayer: GlobalCoordinator.GetRelayer(Web3qChainConf.chainId).(*EthChainRelayer),
+		sourceRelayer: sourceRelayer,
+		targetRelayer: targetRelayer,
 
-		sourceChain: Web3qChainConf.chainId,
-		targetChain: EthereumChainConf.chainId,
+		sourceChain: r.SourceChainId(),
+		targetChain: r.TargetChainId(),
 
 		receiveBurnLog:   make(chan interface{}),
-		receiveHeader:    make(chan *types.Header, 20),
+		receiveHeader:    make(chan *HeaderEvent, 20),
 		beforeSendHeader: make(chan *types.Header, 10),
 		SentHeader:       make(map[uint64]bool),
 
 		status: ScheduleTaskNoStart,
+		pwg:    &manager.wg,
 		ctx:    ctx,
 		cf:     cancelFunc,
 	}
 
-	err := monitorEventTask.SubscribeData(stask.receiveBurnLog)
-	if err != nil {
-		return nil, err
-	}
-
-	err = monitorHeaderTask.SubscribeData(stask.receiveHeader)
-	if err != nil {
-		return nil, err
-	}
-
-	err = stask.SubscribeHeader(submitHeaderTask.receiveCh)
-	if err != nil {
-		return nil, err
-	}
-
-	err = stask.SubscribeBurnLog(recTokenTx.receiveCh)
-	if err != nil {
-		return nil, err
-	}
-
-	manager.
-		AddMonitorTask(monitorEventTask).AddMonitorTask(monitorHeaderTask).
-		AddScheduleTask(stask).
-		AddSubmitTxTask(submitHeaderTask).AddSubmitTxTask(recTokenTx)
-
 	return stask, nil
 }
 
@@ -167,40 +151,10 @@ func (s *ScheduleTask) running() error {
 	for {
 		select {
 		case rlog := <-s.receiveBurnLog:
-			logData := rlog.(*types.Log)
-			// get BurnLog
-			w3qHeaderNum := big.NewInt(0).SetUint64(logData.BlockNumber)
-			header, err := s.w3qRelayer.GetBlockHeader(w3qHeaderNum)
-			if err != nil {
-				log.Error("[ScheduleTask::running()::<-s.receiveBurnLog] failed to w3qRelayer.GetBlockHeader() ", "header", w3qHeaderNum, "schedule-task", s.Name(), "err", err.Error())
-				continue
-			}
-			log.Info("ScheduleTask::running() waiting submit header", "header", w3qHeaderNum, "schedule-task", s.Name())
-			s.beforeSendHeader <- header
-			go func() {
-				time.Sleep(200 * time.Second)
-				// todo : should waiting until submit header tx succeed
-				log.Info("ScheduleTask::running() send log to submit_header_task", "header", w3qHeaderNum, "schedule-task", s.Name())
-				s.sendReceiveTokenSignal <- logData
-			}()
+			go s.schedule(rlog.(*types.Log))
 
 		case header := <-s.beforeSendHeader:
-			log.Info("[ScheduleTask::running()::<-s.beforeSendHeader] preProcess header before sending", "header", header.Number, "schedule-task", s.Name())
-			if s.SentHeader[header.Number.Uint64()] == false {
-
-				exist, err := s.ethRelayer.IsW3qHeaderExistAtLightClient(header.Number)
-				if err != nil {
-					// todo how to process error
-					log.Error("ScheduleTask::running() ethRelayer.IsW3qHeaderExistAtLightClient() happened error", "target-chain", s.targetChain, "schedule-task", s.Name())
-					continue
-				}
-
-				if !exist {
-					log.Info("ScheduleTask::running() send header to submit_header_task", "header", header.Number, "schedule-task", s.Name())
-					s.sendSubmitHeaderSignal <- header
-					s.SentHeader[header.Number.Uint64()] = true
-				}
-			}
+			s.submitHeader(header)
 
 		case <-s.ctx.Done():
 			// todo : delete subscription s.MonitorBurnToken s.MonitorHeader
@@ -208,23 +162,115 @@ func (s *ScheduleTask) running() error {
 			s.SetStatus(ScheduleTaskStopped)
 			return nil
 
-		case header := <-s.receiveHeader:
-			// todo: judge whether header is epoch header
+		case ev := <-s.receiveHeader:
+			header := ev.Header
+			if ev.Type == HeaderReverted {
+				// the header replacing it is sent again if it is the next epoch header
+				log.Warn("ScheduleTask::running() source header reverted by a reorg", "header", header.Number, "hash", header.Hash(), "schedule-task", s.Name())
+				delete(s.SentHeader, header.Number.Uint64())
+				continue
+			}
+			if ev.Type == HeaderReset {
+				// the headers sent before may be reverted unnoticed, the light client is asked again for them
+				log.Warn("ScheduleTask::running() source header stream reset", "header", header.Number, "hash", header.Hash(), "schedule-task", s.Name())
+				s.SentHeader = make(map[uint64]bool)
+			}
 
-			height, err := s.ethRelayer.getNextEpochHeader()
+			height, err := s.targetRelayer.NextEpochHeight(s.route.header, s.route.NextEpochMethod())
 			if err != nil {
-				log.Error("ScheduleTask::running() ethRelayer.getNextEpochHeader() happened error", "target-chain", s.targetChain, "schedule-task", s.Name())
+				log.Error("ScheduleTask::running() targetRelayer.NextEpochHeight() happened error", "target-chain", s.targetChain, "schedule-task", s.Name())
 				continue
 			}
 
+			// the epoch header is handled here, sending it to beforeSendHeader could block on the schedules filling it
 			if height.Cmp(header.Number) == 0 {
-				s.beforeSendHeader <- header
+				s.submitHeader(header)
 			}
 		}
 	}
 }
 
+// submitHeader sends the header to the submit-header task unless it was sent before or the light client holds it
+func (s *ScheduleTask) submitHeader(header *types.Header) {
+	log.Info("ScheduleTask::submitHeader() preProcess header before sending", "header", header.Number, "schedule-task", s.Name())
+	if s.SentHeader[header.Number.Uint64()] {
+		return
+	}
+
+	exist, err := s.targetRelayer.IsHeaderExist(s.route.header, s.route.HeaderExistMethod(), header.Number)
+	if err != nil {
+		// todo how to process error
+		log.Error("ScheduleTask::submitHeader() targetRelayer.IsHeaderExist() happened error", "target-chain", s.targetChain, "schedule-task", s.Name())
+		return
+	}
+
+	if !exist {
+		log.Info("ScheduleTask::submitHeader() send header to submit_header_task", "header", header.Number, "schedule-task", s.Name())
+		select {
+		case s.sendSubmitHeaderSignal <- header:
+			s.SentHeader[header.Number.Uint64()] = true
+		case <-s.ctx.Done():
+		}
+	}
+}
+
+// schedule sends the source header of the log to the submit-header task once its block is final at the source chain,
+// and the log to the submit-tx task once the light client at the target chain holds the header
+func (s *ScheduleTask) schedule(logData *types.Log) {
+	w3qHeaderNum := big.NewInt(0).SetUint64(logData.BlockNumber)
+	log.Info("ScheduleTask::schedule() waiting the source block to be final", "header", w3qHeaderNum, "policy", s.sourceRelayer.ChainConfig.Finality().Policy, "schedule-task", s.Name())
+	if err := s.sourceRelayer.WaitFinalized(s.ctx, logData.BlockNumber); err != nil {
+		return
+	}
+	reverted, err := s.route.reverted(s.sourceRelayer, logData)
+	if err != nil {
+		log.Warn("ScheduleTask::schedule() failed to check the source block of the log", "txHash", logData.TxHash, "logIndex", logData.Index, "schedule-task", s.Name(), "err", err.Error())
+	} else if reverted {
+		log.Warn("ScheduleTask::schedule() drop the log removed by a reorg", "txHash", logData.TxHash, "logIndex", logData.Index, "schedule-task", s.Name())
+		return
+	}
+
+	header, err := s.sourceRelayer.GetBlockHeader(w3qHeaderNum)
+	if err != nil {
+		log.Error("ScheduleTask::schedule() failed to sourceRelayer.GetBlockHeader() ", "header", w3qHeaderNum, "schedule-task", s.Name(), "err", err.Error())
+		return
+	}
+	log.Info("ScheduleTask::schedule() waiting submit header", "header", w3qHeaderNum, "schedule-task", s.Name())
+	select {
+	case s.beforeSendHeader <- header:
+	case <-s.ctx.Done():
+		return
+	}
+	if !s.waitHeaderRelayed(header.Number) {
+		return
+	}
+
+	log.Info("ScheduleTask::schedule() send log to submit_header_task", "header", w3qHeaderNum, "schedule-task", s.Name())
+	if err := s.route.store.Jobs.Update(s.route.Name, logData, JobScheduled, common.Hash{}); err == errJobReverted {
+		log.Warn("ScheduleTask::schedule() drop the log removed by a reorg", "txHash", logData.TxHash, "logIndex", logData.Index, "schedule-task", s.Name())
+		return
+	} else if err != nil {
+		log.Error("ScheduleTask::schedule() failed to update job", "txHash", logData.TxHash, "logIndex", logData.Index, "schedule-task", s.Name(), "err", err.Error())
+	}
+	select {
+	case s.sendReceiveTokenSignal <- logData:
+	case <-s.ctx.Done():
+	}
+}
+
+// waitHeaderRelayed checks at every new head of the target chain whether the light client there holds the header of
+// number, it returns false if the task is stopped first
+func (s *ScheduleTask) waitHeaderRelayed(number *big.Int) bool {
+	err := s.targetRelayer.Heads().WaitUntil(s.ctx, func() bool {
+		exist, err := s.targetRelayer.IsHeaderExist(s.route.header, s.route.HeaderExistMethod(), number)
+		if err != nil {
+			log.Warn("ScheduleTask::waitHeaderRelayed() targetRelayer.IsHeaderExist() happened error", "header", number, "target-chain", s.targetChain, "schedule-task", s.Name(), "err", err.Error())
+		}
+		return exist
+	})
+	return err == nil
+}
+
 func (s *ScheduleTask) TargetChainId() uint64 {
 	panic("ScheduleTask no support TargetChainId()")
-	return 0
 }
@@ -0,0 +1,295 @@
+package v2
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"io"
+	"sort"
+	"strconv"
+)
+
+const (
+	StateJob        = "job"
+	StateCheckpoint = "checkpoint"
+	StateHeader     = "header"    // a cached header, by number and hash
+	StateCanonical  = "canonical" // the canonical hash at a number
+	StateHead       = "head"      // the number of the cached head
+	StateNonce      = "nonce"
+)
+
+// StateRecord is one line of an exported state file, Kind tells which of the other fields are set
+type StateRecord struct {
+	Kind     string          `json:"kind"`
+	ChainId  uint64          `json:"chainId"`
+	Job      *Job            `json:"job,omitempty"`
+	Route    string          `json:"route,omitempty"`
+	Contract *common.Address `json:"contract,omitempty"`
+	EventId  *common.Hash    `json:"eventId,omitempty"`
+	Account  *common.Address `json:"account,omitempty"`
+	Number   uint64          `json:"number,omitempty"`
+	Hash     *common.Hash    `json:"hash,omitempty"`
+	Header   hexutil.Bytes   `json:"header,omitempty"` // the header as kept by the header store
+}
+
+// splitChainKey splits a storage key into the chainId of its namespace and the key inside the namespace
+func splitChainKey(key []byte) (uint64, []byte, error) {
+	if !bytes.HasPrefix(key, chainPrefix) {
+		return 0, nil, fmt.Errorf("key %q out of any chain namespace", key)
+	}
+	rest := key[len(chainPrefix):]
+	i := bytes.IndexByte(rest, '/')
+	if i < 0 {
+		return 0, nil, fmt.Errorf("key %q out of any chain namespace", key)
+	}
+	chainId, err := strconv.ParseUint(string(rest[:i]), 10, 64)
+	if err != nil {
+		return 0, nil, fmt.Errorf("key %q out of any chain namespace", key)
+	}
+	return chainId, rest[i+1:], nil
+}
+
+// decodeStateRecord turns a key and value of a chain namespace into a record
+func decodeStateRecord(chainId uint64, key []byte, value []byte) (*StateRecord, error) {
+	rec := &StateRecord{ChainId: chainId}
+	switch {
+	case bytes.HasPrefix(key, jobPrefix):
+		rec.Kind = StateJob
+		rec.Job = new(Job)
+		if err := json.Unmarshal(value, rec.Job); err != nil {
+			return nil, fmt.Errorf("invalid job %q: %w", key, err)
+		}
+	case bytes.HasPrefix(key, checkpointPrefix):
+		rest := key[len(checkpointPrefix):]
+		i := bytes.IndexByte(rest, '/')
+		if i < 0 || len(rest[i+1:]) != common.AddressLength+common.HashLength || len(value) != 8 {
+			return nil, fmt.Errorf("invalid checkpoint %q", key)
+		}
+		contract, eventId := common.BytesToAddress(rest[i+1:i+1+common.AddressLength]), common.BytesToHash(rest[i+1+common.AddressLength:])
+		rec.Kind, rec.Route, rec.Contract, rec.EventId = StateCheckpoint, string(rest[:i]), &contract, &eventId
+		rec.Number = binary.BigEndian.Uint64(value)
+	case bytes.Equal(key, headerHeadKey):
+		if len(value) != 8 {
+			return nil, fmt.Errorf("invalid header head %x", value)
+		}
+		rec.Kind, rec.Number = StateHead, binary.BigEndian.Uint64(value)
+	case bytes.HasPrefix(key, headerCanonicalPfx):
+		rest := key[len(headerCanonicalPfx):]
+		if len(rest) != 8 {
+			return nil, fmt.Errorf("invalid canonical header key %q", key)
+		}
+		hash := common.BytesToHash(value)
+		rec.Kind, rec.Number, rec.Hash = StateCanonical, binary.BigEndian.Uint64(rest), &hash
+	case bytes.HasPrefix(key, headerPfx):
+		rest := key[len(headerPfx):]
+		if len(rest) != 8+common.HashLength {
+			return nil, fmt.Errorf("invalid header key %q", key)
+		}
+		hash := common.BytesToHash(rest[8:])
+		rec.Kind, rec.Number, rec.Hash, rec.Header = StateHeader, binary.BigEndian.Uint64(rest[:8]), &hash, common.CopyBytes(value)
+	case bytes.HasPrefix(key, headerNumberPfx):
+		// the index by hash is rebuilt from the header record
+		return nil, nil
+	case bytes.HasPrefix(key, noncePrefix):
+		rest := key[len(noncePrefix):]
+		if len(rest) != common.AddressLength || len(value) != 8 {
+			return nil, fmt.Errorf("invalid nonce %q", key)
+		}
+		account := common.BytesToAddress(rest)
+		rec.Kind, rec.Account, rec.Number = StateNonce, &account, binary.BigEndian.Uint64(value)
+	default:
+		return nil, fmt.Errorf("unknown key %q", key)
+	}
+	return rec, nil
+}
+
+// write puts the keys and values of the record into w, without the chain namespace
+func (rec *StateRecord) write(w ethdb.KeyValueWriter) error {
+	switch rec.Kind {
+	case StateJob:
+		if rec.Job == nil {
+			return errors.New("job record without job")
+		}
+		// marshalled as is, JobStore.Put would touch UpdatedAt
+		b, err := json.Marshal(rec.Job)
+		if err != nil {
+			return err
+		}
+		return w.Put(jobKey(rec.Job.Route, rec.Job.ChainId, rec.Job.TxHash, rec.Job.LogIndex), b)
+	case StateCheckpoint:
+		if rec.Route == "" || rec.Contract == nil || rec.EventId == nil {
+			return errors.New("checkpoint record without route, contract or eventId")
+		}
+		return w.Put(checkpointKey(rec.Route, *rec.Contract, *rec.EventId), encodeNumber(rec.Number))
+	case StateHead:
+		return w.Put(headerHeadKey, encodeNumber(rec.Number))
+	case StateCanonical:
+		if rec.Hash == nil {
+			return errors.New("canonical record without hash")
+		}
+		return w.Put(headerCanonicalKey(rec.Number), rec.Hash.Bytes())
+	case StateHeader:
+		if rec.Hash == nil || len(rec.Header) == 0 {
+			return errors.New("header record without hash or header")
+		}
+		if _, err := decodeHeader(rec.Header); err != nil {
+			return fmt.Errorf("invalid header %s: %w", rec.Hash.Hex(), err)
+		}
+		if err := w.Put(headerKey(rec.Number, *rec.Hash), rec.Header); err != nil {
+			return err
+		}
+		return w.Put(headerNumberKey(*rec.Hash), encodeNumber(rec.Number))
+	case StateNonce:
+		if rec.Account == nil {
+			return errors.New("nonce record without account")
+		}
+		return w.Put(append(append([]byte{}, noncePrefix...), rec.Account.Bytes()...), encodeNumber(rec.Number))
+	default:
+		return fmt.Errorf("unknown record kind [%s]", rec.Kind)
+	}
+}
+
+// ExportState writes the jobs, checkpoints, cached headers and nonces of all chains in storage to w as JSON lines,
+// it returns the number of records written
+func ExportState(storage Storage, w io.Writer) (int, error) {
+	it := storage.NewIterator(chainPrefix, nil)
+	defer it.Release()
+
+	enc := json.NewEncoder(w)
+	count := 0
+	for it.Next() {
+		chainId, key, err := splitChainKey(it.Key())
+		if err != nil {
+			return count, err
+		}
+		rec, err := decodeStateRecord(chainId, key, it.Value())
+		if err != nil {
+			return count, fmt.Errorf("chain %d: %w", chainId, err)
+		}
+		if rec == nil {
+			continue
+		}
+		if err = enc.Encode(rec); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, it.Error()
+}
+
+// ImportState reads the JSON lines written by ExportState into storage, which must hold no chain state yet.
+// It returns the number of records imported. The records are staged in one batch written at the end, so a failed
+// import leaves the storage empty and can be run again.
+func ImportState(storage Storage, r io.Reader) (int, error) {
+	it := storage.NewIterator(chainPrefix, nil)
+	empty := !it.Next()
+	it.Release()
+	if !empty {
+		return 0, errors.New("the storage holds relayer state already")
+	}
+
+	batch := storage.NewBatch()
+	namespaces := make(map[uint64]*namespaceBatch)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	count := 0
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		rec := new(StateRecord)
+		if err := json.Unmarshal(scanner.Bytes(), rec); err != nil {
+			return 0, fmt.Errorf("line %d: %w", line, err)
+		}
+		nb, ok := namespaces[rec.ChainId]
+		if !ok {
+			nb = &namespaceBatch{Batch: batch, ns: &namespace{prefix: []byte(chainNamespace(rec.ChainId)), db: storage}}
+			namespaces[rec.ChainId] = nb
+		}
+		if err := rec.write(nb); err != nil {
+			return 0, fmt.Errorf("line %d: %w", line, err)
+		}
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return count, batch.Write()
+}
+
+// RouteSummary counts the jobs of a route by stage
+type RouteSummary struct {
+	ChainId       uint64
+	Route         string
+	Stages        map[JobStage]int
+	Disputed      int  // the jobs that quorum providers disagreed on
+	OldestPending *Job // the unfinished job created first, nil if none
+}
+
+// StateSummary is the overview of the relayer state printed by the inspect command
+type StateSummary struct {
+	Routes      []*RouteSummary
+	Checkpoints []*StateRecord
+	Heads       []*StateRecord // the cached head per chain
+}
+
+// InspectState summarizes the jobs per route, the checkpoints and the cached heads of all chains in storage
+func InspectState(storage Storage) (*StateSummary, error) {
+	it := storage.NewIterator(chainPrefix, nil)
+	defer it.Release()
+
+	summary := new(StateSummary)
+	routes := make(map[string]*RouteSummary)
+	for it.Next() {
+		chainId, key, err := splitChainKey(it.Key())
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.HasPrefix(key, jobPrefix) && !bytes.HasPrefix(key, checkpointPrefix) && !bytes.Equal(key, headerHeadKey) {
+			continue
+		}
+		rec, err := decodeStateRecord(chainId, key, it.Value())
+		if err != nil {
+			return nil, fmt.Errorf("chain %d: %w", chainId, err)
+		}
+
+		switch rec.Kind {
+		case StateJob:
+			id := fmt.Sprintf("%d/%s", chainId, rec.Job.Route)
+			rs, ok := routes[id]
+			if !ok {
+				rs = &RouteSummary{ChainId: chainId, Route: rec.Job.Route, Stages: make(map[JobStage]int)}
+				routes[id] = rs
+				summary.Routes = append(summary.Routes, rs)
+			}
+			rs.Stages[rec.Job.Stage]++
+			if len(rec.Job.Disagreements) != 0 {
+				rs.Disputed++
+			}
+			if !rec.Job.Finished() && (rs.OldestPending == nil || rec.Job.CreatedAt < rs.OldestPending.CreatedAt) {
+				rs.OldestPending = rec.Job
+			}
+		case StateCheckpoint:
+			summary.Checkpoints = append(summary.Checkpoints, rec)
+		case StateHead:
+			summary.Heads = append(summary.Heads, rec)
+		}
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(summary.Routes, func(i, j int) bool {
+		if summary.Routes[i].ChainId != summary.Routes[j].ChainId {
+			return summary.Routes[i].ChainId < summary.Routes[j].ChainId
+		}
+		return summary.Routes[i].Route < summary.Routes[j].Route
+	})
+	return summary, nil
+}
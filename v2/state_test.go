@@ -0,0 +1,93 @@
+package v2
+
+import (
+	"bytes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"math/big"
+	"testing"
+)
+
+func TestExportImportState(t *testing.T) {
+	storage := NewMemoryStorage()
+	w3q, eth := NewChainStore(storage, 3333, 0), NewChainStore(storage, 5, 0)
+	contract, eventId := common.HexToAddress("0x01"), common.HexToHash("0x02")
+
+	logs := []*types.Log{
+		{TxHash: common.HexToHash("0x03"), Index: 1, BlockNumber: 20, Topics: []common.Hash{}, Data: []byte{}},
+		{TxHash: common.HexToHash("0x04"), Index: 0, BlockNumber: 30, Topics: []common.Hash{}, Data: []byte{}},
+	}
+	for _, l := range logs {
+		if _, err := w3q.ObserveLog("w3q-to-eth", contract, eventId, l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w3q.Jobs.Update("w3q-to-eth", logs[0], JobSubmitted, common.HexToHash("0xaa")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w3q.Jobs.Update("w3q-to-eth", logs[0], JobConfirmed, common.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	genesis := &types.Header{Number: big.NewInt(0), Difficulty: big.NewInt(1)}
+	for _, header := range append([]*types.Header{genesis}, makeHeaders(genesis, 3, 'a')...) {
+		if err := w3q.Headers.InsertHead(header, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := eth.Nonces.Put(common.HexToAddress("0x05"), 9); err != nil {
+		t.Fatal(err)
+	}
+
+	var exported bytes.Buffer
+	count, err := ExportState(storage, &exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2 jobs, 1 checkpoint, 4 headers, 4 canonical hashes, the head and the nonce
+	if count != 13 {
+		t.Fatalf("exported %d records", count)
+	}
+
+	// a file failing at its last line leaves nothing written, so the import can be run again
+	restored := NewMemoryStorage()
+	broken := append(append([]byte{}, exported.Bytes()...), "{\"kind\": \"unknown\"}\n"...)
+	if _, err = ImportState(restored, bytes.NewReader(broken)); err == nil {
+		t.Fatal("imported a file with an unknown record")
+	}
+	if _, err = ImportState(restored, bytes.NewReader(exported.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	var reexported bytes.Buffer
+	if _, err = ExportState(restored, &reexported); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(exported.Bytes(), reexported.Bytes()) {
+		t.Fatalf("restored state differs\n%s\n%s", exported.String(), reexported.String())
+	}
+
+	store := NewChainStore(restored, 3333, 0)
+	header, err := store.Headers.HeaderByNumber(2)
+	if err != nil || header == nil || header.Number.Uint64() != 2 {
+		t.Fatalf("unexpected restored header %+v err %v", header, err)
+	}
+	if _, err = ImportState(restored, bytes.NewReader(exported.Bytes())); err == nil {
+		t.Fatal("imported into a storage holding state")
+	}
+
+	summary, err := InspectState(restored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Routes) != 1 || summary.Routes[0].Stages[JobConfirmed] != 1 || summary.Routes[0].Stages[JobObserved] != 1 {
+		t.Fatalf("unexpected route summary %+v", summary.Routes)
+	}
+	if job := summary.Routes[0].OldestPending; job == nil || job.TxHash != logs[1].TxHash {
+		t.Fatalf("unexpected oldest pending job %+v", job)
+	}
+	if len(summary.Checkpoints) != 1 || summary.Checkpoints[0].Number != 29 {
+		t.Fatalf("unexpected checkpoints %+v", summary.Checkpoints)
+	}
+	if len(summary.Heads) != 1 || summary.Heads[0].ChainId != 3333 || summary.Heads[0].Number != 3 {
+		t.Fatalf("unexpected heads %+v", summary.Heads)
+	}
+}
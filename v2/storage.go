@@ -0,0 +1,230 @@
+package v2
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/leveldb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"io"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	LevelDBStorage = "leveldb"
+	MemoryStorage  = "memory"
+)
+
+// Storage is the key-value backend shared by all chain relayers of a process. Batches are written atomically.
+type Storage interface {
+	ethdb.KeyValueReader
+	ethdb.KeyValueWriter
+	ethdb.Iteratee
+	NewBatch() ethdb.Batch
+	io.Closer
+}
+
+// StorageConfig selects the storage backend, dir is resolved against the data directory if relative
+type StorageConfig struct {
+	Engine string `json:"engine,omitempty"` // leveldb (default) or memory
+	Dir    string `json:"dir,omitempty"`    // leveldb directory, defaults to relayerdb
+}
+
+func (c StorageConfig) validate() error {
+	switch c.Engine {
+	case "", LevelDBStorage, MemoryStorage:
+		return nil
+	default:
+		return fmt.Errorf("unknown storage engine [%s]", c.Engine)
+	}
+}
+
+func OpenStorage(c StorageConfig, dataDir string) (Storage, error) {
+	switch c.Engine {
+	case MemoryStorage:
+		return NewMemoryStorage(), nil
+	case "", LevelDBStorage:
+		dir := c.Dir
+		if dir == "" {
+			dir = "relayerdb"
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(dataDir, dir)
+		}
+		return NewLevelDBStorage(dir)
+	default:
+		return nil, fmt.Errorf("unknown storage engine [%s]", c.Engine)
+	}
+}
+
+func NewLevelDBStorage(dir string) (Storage, error) {
+	return leveldb.New(dir, 16, 16, "relayer", false)
+}
+
+// NewMemoryStorage keeps everything in memory, it is meant for tests
+func NewMemoryStorage() Storage {
+	return memorydb.New()
+}
+
+// namespace prefixes every key written to the storage, so that several users can share it
+type namespace struct {
+	prefix []byte
+	db     Storage
+}
+
+func newNamespace(db Storage, prefix string) Storage {
+	return &namespace{prefix: []byte(prefix), db: db}
+}
+
+func (ns *namespace) key(key []byte) []byte {
+	return append(append([]byte{}, ns.prefix...), key...)
+}
+
+func (ns *namespace) Has(key []byte) (bool, error) {
+	return ns.db.Has(ns.key(key))
+}
+
+func (ns *namespace) Get(key []byte) ([]byte, error) {
+	return ns.db.Get(ns.key(key))
+}
+
+func (ns *namespace) Put(key []byte, value []byte) error {
+	return ns.db.Put(ns.key(key), value)
+}
+
+func (ns *namespace) Delete(key []byte) error {
+	return ns.db.Delete(ns.key(key))
+}
+
+func (ns *namespace) NewIterator(prefix []byte, start []byte) ethdb.Iterator {
+	return &namespaceIterator{Iterator: ns.db.NewIterator(ns.key(prefix), start), prefix: len(ns.prefix)}
+}
+
+func (ns *namespace) NewBatch() ethdb.Batch {
+	return &namespaceBatch{Batch: ns.db.NewBatch(), ns: ns}
+}
+
+// Close leaves the shared storage open
+func (ns *namespace) Close() error {
+	return nil
+}
+
+type namespaceIterator struct {
+	ethdb.Iterator
+	prefix int
+}
+
+func (it *namespaceIterator) Key() []byte {
+	key := it.Iterator.Key()
+	if key == nil {
+		return nil
+	}
+	return key[it.prefix:]
+}
+
+type namespaceBatch struct {
+	ethdb.Batch
+	ns *namespace
+}
+
+func (b *namespaceBatch) Put(key []byte, value []byte) error {
+	return b.Batch.Put(b.ns.key(key), value)
+}
+
+func (b *namespaceBatch) Delete(key []byte) error {
+	return b.Batch.Delete(b.ns.key(key))
+}
+
+func (b *namespaceBatch) Replay(w ethdb.KeyValueWriter) error {
+	return b.Batch.Replay(&namespaceReplayer{w: w, prefix: len(b.ns.prefix)})
+}
+
+type namespaceReplayer struct {
+	w      ethdb.KeyValueWriter
+	prefix int
+}
+
+func (r *namespaceReplayer) Put(key []byte, value []byte) error {
+	return r.w.Put(key[r.prefix:], value)
+}
+
+func (r *namespaceReplayer) Delete(key []byte) error {
+	return r.w.Delete(key[r.prefix:])
+}
+
+// ChainStore is the namespace of a chain in the shared storage, holding the jobs and checkpoints of the routes
+// relaying its logs, its recent headers and the nonces of the relayer account on it
+type ChainStore struct {
+	db          Storage
+	Jobs        *JobStore
+	Checkpoints *CheckpointStore
+	Headers     *HeaderStore
+	Nonces      *NonceStore
+}
+
+var chainPrefix = []byte("chain/")
+
+// chainNamespace is chain/<chainId>/
+func chainNamespace(chainId uint64) string {
+	return string(chainPrefix) + strconv.FormatUint(chainId, 10) + "/"
+}
+
+func NewChainStore(storage Storage, chainId uint64, headerCacheDepth uint64) *ChainStore {
+	db := newNamespace(storage, chainNamespace(chainId))
+	return &ChainStore{
+		db:          db,
+		Jobs:        NewJobStore(chainId, db),
+		Checkpoints: NewCheckpointStore(db),
+		Headers:     NewHeaderStore(db, headerCacheDepth),
+		Nonces:      NewNonceStore(db),
+	}
+}
+
+// ObserveLog records the log as a new job of the route and moves the checkpoint of the route to the block before
+// the log in one batch. It returns false if the log has been observed before.
+func (s *ChainStore) ObserveLog(route string, contract common.Address, eventId common.Hash, l *types.Log) (bool, error) {
+	s.Jobs.lock.Lock()
+	defer s.Jobs.lock.Unlock()
+	batch := s.db.NewBatch()
+	isNew, err := s.Jobs.observe(batch, route, l)
+	if err != nil || !isNew {
+		return false, err
+	}
+	if l.BlockNumber > 0 {
+		if err = s.Checkpoints.put(batch, route, contract, eventId, l.BlockNumber-1); err != nil {
+			return false, err
+		}
+	}
+	return true, batch.Write()
+}
+
+var noncePrefix = []byte("nonce/")
+
+// NonceStore persists the nonce of the last tx submitted by an account
+type NonceStore struct {
+	db Storage
+}
+
+func NewNonceStore(db Storage) *NonceStore {
+	return &NonceStore{db: db}
+}
+
+func (s *NonceStore) Get(account common.Address) (nonce uint64, ok bool, err error) {
+	key := append(append([]byte{}, noncePrefix...), account.Bytes()...)
+	has, err := s.db.Has(key)
+	if err != nil || !has {
+		return 0, false, err
+	}
+	b, err := s.db.Get(key)
+	if err != nil {
+		return 0, false, err
+	}
+	return binary.BigEndian.Uint64(b), true, nil
+}
+
+func (s *NonceStore) Put(account common.Address, nonce uint64) error {
+	return s.db.Put(append(append([]byte{}, noncePrefix...), account.Bytes()...), encodeNumber(nonce))
+}
@@ -0,0 +1,62 @@
+package v2
+
+import (
+	"bytes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"testing"
+)
+
+func TestChainStoreNamespace(t *testing.T) {
+	storage := NewMemoryStorage()
+	w3q, eth := NewChainStore(storage, 3333, 0), NewChainStore(storage, 5, 0)
+	account := common.HexToAddress("0x01")
+
+	if err := w3q.Nonces.Put(account, 7); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := eth.Nonces.Get(account); err != nil || ok {
+		t.Fatalf("nonce leaked into another chain: ok %v err %v", ok, err)
+	}
+	if nonce, ok, err := w3q.Nonces.Get(account); err != nil || !ok || nonce != 7 {
+		t.Fatalf("unexpected nonce %d ok %v err %v", nonce, ok, err)
+	}
+
+	// the keys iterated in a namespace carry no namespace prefix
+	it := w3q.db.NewIterator(noncePrefix, nil)
+	defer it.Release()
+	if !it.Next() || !bytes.Equal(it.Key(), append(append([]byte{}, noncePrefix...), account.Bytes()...)) {
+		t.Fatalf("unexpected iterated key %x", it.Key())
+	}
+	if it.Next() {
+		t.Fatalf("unexpected second key %x", it.Key())
+	}
+}
+
+func TestChainStoreObserveLog(t *testing.T) {
+	store := NewChainStore(NewMemoryStorage(), 3333, 0)
+	contract, eventId := common.HexToAddress("0x01"), common.HexToHash("0x02")
+	l := &types.Log{TxHash: common.HexToHash("0x03"), Index: 1, BlockNumber: 20, Topics: []common.Hash{}, Data: []byte{}}
+
+	isNew, err := store.ObserveLog("w3q-to-eth", contract, eventId, l)
+	if err != nil || !isNew {
+		t.Fatalf("observe log: new %v err %v", isNew, err)
+	}
+	if job, err := store.Jobs.Get("w3q-to-eth", l.TxHash, l.Index); err != nil || job == nil || job.Stage != JobObserved {
+		t.Fatalf("unexpected job %+v err %v", job, err)
+	}
+	if number, ok, err := store.Checkpoints.Get("w3q-to-eth", contract, eventId); err != nil || !ok || number != 19 {
+		t.Fatalf("unexpected checkpoint %d ok %v err %v", number, ok, err)
+	}
+
+	// a log observed before leaves the checkpoint where it is
+	if err = store.Checkpoints.Put("w3q-to-eth", contract, eventId, 30); err != nil {
+		t.Fatal(err)
+	}
+	if isNew, err = store.ObserveLog("w3q-to-eth", contract, eventId, l); err != nil || isNew {
+		t.Fatalf("observe the same log twice: new %v err %v", isNew, err)
+	}
+	if number, _, _ := store.Checkpoints.Get("w3q-to-eth", contract, eventId); number != 30 {
+		t.Fatalf("checkpoint moved by a duplicate log to %d", number)
+	}
+}
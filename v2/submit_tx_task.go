@@ -1,15 +1,14 @@
 package v2
 
 import (
+	"context"
 	"fmt"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
-	"github.com/ethereum/go-ethereum/rlp"
 	"math/big"
 	"sync"
 	"sync/atomic"
-	"time"
 )
 
 const (
@@ -31,13 +30,16 @@ type (
 
 		submitTxFunc func(source *EthChainRelayer, target *EthChainRelayer, value interface{}, task *SubmitTxTask) (*types.Transaction, error)
 
-		pwg       sync.WaitGroup
+		registry  RelayerRegistry
+		pwg       *sync.WaitGroup
 		receiveCh chan interface{}
-		cancelCh  chan struct{}
+		ctx       context.Context
+		cf        context.CancelFunc
 	}
 )
 
-func NewSubmitTxTask(caddr common.Address, cName, mName string, sourceChainId uint64, targetChainId uint64, pwg sync.WaitGroup) *SubmitTxTask {
+func NewSubmitTxTask(pctx context.Context, caddr common.Address, cName, mName string, sourceChainId uint64, targetChainId uint64, registry RelayerRegistry, pwg *sync.WaitGroup) *SubmitTxTask {
+	ctx, cancelFunc := context.WithCancel(pctx)
 	return &SubmitTxTask{
 		contractAddr:  caddr,
 		contractName:  cName,
@@ -46,7 +48,9 @@ func NewSubmitTxTask(caddr common.Address, cName, mName string, sourceChainId ui
 		sourceChainId: sourceChainId,
 		targetChainId: targetChainId,
 		receiveCh:     make(chan interface{}),
-		cancelCh:      make(chan struct{}),
+		ctx:           ctx,
+		cf:            cancelFunc,
+		registry:      registry,
 		pwg:           pwg,
 	}
 }
@@ -75,12 +79,12 @@ func (et *SubmitTxTask) running() error {
 		select {
 		case data := <-et.receiveCh:
 			//err := et.execFunc(data)
-			sr := GlobalCoordinator.GetRelayer(et.sourceChainId).(*EthChainRelayer)
-			tr := GlobalCoordinator.GetRelayer(et.targetChainId).(*EthChainRelayer)
-			if sr == nil {
+			sr, ok := et.registry.GetRelayer(et.sourceChainId).(*EthChainRelayer)
+			if !ok {
 				return fmt.Errorf("chainRelayer %d no exist", et.sourceChainId)
 			}
-			if tr == nil {
+			tr, ok := et.registry.GetRelayer(et.targetChainId).(*EthChainRelayer)
+			if !ok {
 				return fmt.Errorf("chainRelayer %d no exist", et.targetChainId)
 			}
 
@@ -88,13 +92,12 @@ func (et *SubmitTxTask) running() error {
 			// todo : how to deal failed tx
 			if err != nil {
 				log.Error("SubmitTxTask::running() failed to submitTx", "chainId", et.TargetChainId(), "methodName", et.methodName, "error", err.Error())
-				fmt.Printf("tx: %v", *tx)
 				continue
 			}
 
 			log.Info("SubmitTxTask::running() succeed to submitTx", "chainId", et.TargetChainId(), "txhash", tx.Hash(), "methodName", et.methodName)
 
-		case <-et.cancelCh:
+		case <-et.ctx.Done():
 			et.SetStatus(SubmitTxTaskStopped)
 			return nil
 		}
@@ -106,14 +109,14 @@ func (task *SubmitTxTask) TargetChainId() uint64 {
 }
 
 func (task *SubmitTxTask) Stop() error {
-	log.Info("MonitorTask::Stop() send the stop-signal to the monitor-contract-event task ", "chainId", task.TargetChainId(), "contract", task.contractAddr)
+	log.Info("SubmitTxTask::Stop() send the stop-signal to the submit-tx task", "chainId", task.TargetChainId(), "contract", task.contractAddr, "method", task.methodName)
 	if task.Status() == SubmitTxTaskDoing {
-		task.cancelCh <- struct{}{}
 		task.SetStatus(SubmitTxTaskStopping)
+		task.cf()
 		return nil
 	}
 
-	return fmt.Errorf("MonitorTask::Stop() failed to stop the monitor-contract-event task execution")
+	return fmt.Errorf("SubmitTxTask::Stop() failed to stop the submit-tx task execution")
 
 }
 
@@ -131,33 +134,41 @@ type Proof struct {
 	HpKey     []byte
 }
 
-func (manager *TaskManager) GenReceiveToken_SubmitTxTask_OnEth() *SubmitTxTask {
-	task := NewSubmitTxTask(EthereumChainConf.bridgeAddr, EthereumBridgeContract, receiveFromWeb3qFunc, Web3qChainConf.chainId, EthereumChainConf.chainId, manager.wg)
+// GenRoute_SubmitTxTask calls the target method of the route for every event log it receives
+func (manager *TaskManager) GenRoute_SubmitTxTask(r *Route) *SubmitTxTask {
+	task := NewSubmitTxTask(manager.ctx, r.target.Addr, r.target.Name, r.Target.Method, r.SourceChainId(), r.TargetChainId(), manager.registry, &manager.wg)
 	ef := func(source *EthChainRelayer, target *EthChainRelayer, value interface{}, task *SubmitTxTask) (*types.Transaction, error) {
-		log := value.(*types.Log)
-		// 4. get receipt_proof from web3q
-		proof, err := source.GetReceiptProof(log.TxHash)
+		// the block of the log is final already, by the finality gate or the schedule task of the route
+		l := value.(*types.Log)
+		reverted, err := r.reverted(source, l)
 		if err != nil {
-			return nil, err
+			log.Warn("SubmitTxTask::running() failed to check the source block of the log", "route", r.Name, "txHash", l.TxHash, "logIndex", l.Index, "err", err.Error())
 		}
-
-		p := &Proof{
-			Value:     proof.ReceiptValue,
-			ProofPath: proof.ReceiptValue,
-			HpKey:     proof.ReceiptKey,
+		if reverted {
+			log.Warn("SubmitTxTask::running() drop the log removed by a reorg", "route", r.Name, "txHash", l.TxHash, "logIndex", l.Index)
+			return nil, errLogReverted
 		}
 
-		logIndex := big.NewInt(0).SetUint64(uint64(log.Index))
-		fmt.Printf("===%d ==== %d====", log.Index, logIndex.Uint64())
-		tx, err := target.GenTx(task, big.NewInt(0).SetUint64(log.BlockNumber), p, logIndex)
-		if err != nil {
-			return tx, err
-		}
-		err = target.SubmitTx(tx)
+		tx, err := r.submit(source, target, task, l)
 		if err != nil {
+			if jerr := r.store.Jobs.Fail(r.Name, l, err); jerr != nil {
+				log.Error("SubmitTxTask::running() failed to record job error", "route", r.Name, "txHash", l.TxHash, "logIndex", l.Index, "err", jerr.Error())
+			}
 			return tx, err
 		}
 
+		// a job reverted while its tx was built has its tx cancelled, the revert missed it
+		if jerr := r.store.Jobs.Update(r.Name, l, JobSubmitted, tx.Hash()); jerr == errJobReverted {
+			log.Warn("SubmitTxTask::running() cancel the tx of the log removed by a reorg", "route", r.Name, "txHash", l.TxHash, "logIndex", l.Index, "targetTx", tx.Hash())
+			if _, cerr := target.CancelTx(tx.Hash()); cerr != nil {
+				log.Error("SubmitTxTask::running() failed to cancel the pending tx", "route", r.Name, "txHash", l.TxHash, "logIndex", l.Index, "targetTx", tx.Hash(), "err", cerr.Error())
+			}
+			return nil, errLogReverted
+		} else if jerr != nil {
+			log.Error("SubmitTxTask::running() failed to update job", "route", r.Name, "txHash", l.TxHash, "logIndex", l.Index, "err", jerr.Error())
+		}
+		// the job is finished once the tx succeeded, a failed or dropped tx is submitted again
+		go r.confirm(task.ctx, target, l, tx.Hash(), task.receiveCh)
 		return tx, nil
 	}
 
@@ -165,95 +176,66 @@ func (manager *TaskManager) GenReceiveToken_SubmitTxTask_OnEth() *SubmitTxTask {
 	return task
 }
 
-func (manager *TaskManager) GenSubmitWeb3qHeader_SubmitTxTask_OnEth() *SubmitTxTask {
-	task := NewSubmitTxTask(EthereumChainConf.lightClientAddr, LightClientContract, SubmitHeaderFunc, Web3qChainConf.chainId, EthereumChainConf.chainId, manager.wg)
+// GenSubmitHeader_SubmitTxTask submits the source headers it receives to the light client of the route
+func (manager *TaskManager) GenSubmitHeader_SubmitTxTask(r *Route) *SubmitTxTask {
+	task := NewSubmitTxTask(manager.ctx, r.header.Addr, r.header.Name, r.SubmitHeaderMethod(), r.SourceChainId(), r.TargetChainId(), manager.registry, &manager.wg)
 	ef := func(source *EthChainRelayer, target *EthChainRelayer, value interface{}, task *SubmitTxTask) (*types.Transaction, error) {
-		web3qHeader := value.(*types.Header)
+		header := value.(*types.Header)
 
-		//3. submit Header to Ethereum
-		eHeader, eCommit, err := PackedWeb3qHeader(web3qHeader)
+		eHeader, eCommit, err := source.Family().PackHeader(header)
 		if err != nil {
 			return nil, err
 		}
 
-		// todo: getNonce
-
-		tx, err := target.GenTx(task, web3qHeader.Number, eHeader, eCommit, false)
+		tx, err := target.GenTx(task, header.Number, eHeader, eCommit, false)
 		if err != nil {
 			return tx, err
 		}
 
-		err = target.SubmitTx(tx)
+		signedTx, err := target.SubmitTx(tx)
 		if err != nil {
 			return tx, err
 		}
 
 		// todo : set the current txHash for exec_task , and track the tx
 
-		return tx, nil
+		return signedTx, nil
 	}
 
 	task.submitTxFunc = ef
 	return task
 }
 
-const CONFIRMS = 2
-const BlockInternalSecond = 10
 const RetryTimes = 3
 
-func (manager *TaskManager) GenReceiveToken_SubmitTxTask_OnWeb3q() *SubmitTxTask {
-	task := NewSubmitTxTask(Web3qChainConf.bridgeAddr, Web3qBridgeContract, receiveFromEthFunc, EthereumChainConf.chainId, Web3qChainConf.chainId, manager.wg)
+func (manager *TaskManager) GenReceiveToken_SubmitTxTask_OnWeb3q(ethConf *ChainConfig, w3qConf *ChainConfig) *SubmitTxTask {
+	task := NewSubmitTxTask(manager.ctx, w3qConf.bridgeAddr, Web3qBridgeContract, receiveFromEthFunc, ethConf.chainId, w3qConf.chainId, manager.registry, &manager.wg)
 	ef := func(source *EthChainRelayer, target *EthChainRelayer, value interface{}, task *SubmitTxTask) (*types.Transaction, error) {
 		logData := value.(*types.Log)
 
-		sourceExpectBN := logData.BlockNumber + CONFIRMS
+		log.Info("submit-task submitting tx:: waiting the source block to be final", "block", logData.BlockNumber, "policy", source.ChainConfig.Finality().Policy)
+		if err := source.WaitFinalized(task.ctx, logData.BlockNumber); err != nil {
+			return nil, err
+		}
 		retryNonce := 0
 		for {
-			sourceLatestBN := source.latestHeaderNum.Uint64()
-			log.Info("submit-task submitting tx:: waiting enough confirms", "current-block", sourceLatestBN, "expect-block", sourceExpectBN)
-			if sourceLatestBN >= sourceExpectBN {
-				tx, err := target.GenTx(task, logData.TxHash, big.NewInt(0))
-				if err != nil {
-					log.Error("submit-task submitting tx:: generate tx err ", "submit-task", task.Name(), "targetChain", task.TargetChainId())
-					return tx, err
-				}
-				err = target.SubmitTx(tx)
-				if err != nil && retryNonce <= RetryTimes {
-					log.Error("submit-task submitting tx:: happen error and retrying", "submit-task", task.Name(), "targetChain", task.TargetChainId())
-					retryNonce++
-					continue
-				}
-
+			tx, err := target.GenTx(task, logData.TxHash, big.NewInt(0))
+			if err != nil {
+				log.Error("submit-task submitting tx:: generate tx err ", "submit-task", task.Name(), "targetChain", task.TargetChainId())
 				return tx, err
 			}
-
-			//var ss int = int(sourceExpectBN-sourceLatestBN) * BlockInternalSecond
-			time.Sleep(BlockInternalSecond * time.Second)
+			signedTx, err := target.SubmitTx(tx)
+			if err != nil && retryNonce <= RetryTimes {
+				log.Error("submit-task submitting tx:: happen error and retrying", "submit-task", task.Name(), "targetChain", task.TargetChainId())
+				retryNonce++
+				continue
+			}
+			if err != nil {
+				return tx, err
+			}
+			return signedTx, nil
 		}
-
 	}
 	task.submitTxFunc = ef
 	return task
 }
-
-func PackedWeb3qHeader(header *types.Header) ([]byte, []byte, error) {
-	cph := types.CopyHeader(header)
-	cph.Commit = nil
-	eHeader, err := rlp.EncodeToBytes(cph)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	cph = types.CopyHeader(header)
-	cph.Commit = nil
-	eHeader, err = rlp.EncodeToBytes(cph)
-	if err != nil {
-		return nil, nil, err
-	}
-	eCommit, err := rlp.EncodeToBytes(header.Commit)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	return eHeader, eCommit, nil
-}
@@ -3,6 +3,7 @@ package v2
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/ethereum/go-ethereum/log"
 	"sync"
 	"sync/atomic"
@@ -22,10 +23,24 @@ const (
 	ScheduleTaskType      = 3
 )
 
+// RelayerRegistry resolves the chain relayers that tasks are executed against
+type RelayerRegistry interface {
+	GetRelayer(chainId uint64) IChainRelayer
+	SendTaskToRelayer(task IMonitorTask) error
+}
+
 // send task to
 type TaskManager struct {
 	*TaskPool
 
+	registry  RelayerRegistry
+	contracts ContractsConfig
+	routes    map[string]*routeTasks
+
+	// lock guards the task pool, routes and contracts, which change when routes are added or removed at runtime
+	lock    sync.Mutex
+	started bool
+
 	wg         sync.WaitGroup
 	ctx        context.Context
 	cancelFunc context.CancelFunc
@@ -33,11 +48,10 @@ type TaskManager struct {
 	status uint32
 }
 
-func NewTaskManager(ctx context.Context) *TaskManager {
+func NewTaskManager(ctx context.Context, registry RelayerRegistry, contracts ContractsConfig) *TaskManager {
 	taskPool := NewTaskPool()
-	wg := sync.WaitGroup{}
 	sctx, cancelFunc := context.WithCancel(ctx)
-	return &TaskManager{TaskPool: taskPool, wg: wg, ctx: sctx, cancelFunc: cancelFunc, status: TaskManagerNoStart}
+	return &TaskManager{TaskPool: taskPool, registry: registry, contracts: contracts, routes: make(map[string]*routeTasks), ctx: sctx, cancelFunc: cancelFunc, status: TaskManagerNoStart}
 }
 
 func (manager *TaskManager) Start() error {
@@ -56,30 +70,23 @@ func (manager *TaskManager) running() error {
 		return errors.New("TaskManager running with invalid status")
 	}
 
-	for _, stask := range manager.scheduleQueue {
-		go func(t *ScheduleTask) {
-			err := stask.Start()
-			if err != nil {
-				panic(err)
-			}
-		}(stask)
+	// the routes added from now on start their own tasks
+	manager.lock.Lock()
+	manager.started = true
+	monitors := append([]IMonitorTask{}, manager.monitorQueue...)
+	schedules := append([]*ScheduleTask{}, manager.scheduleQueue...)
+	txs := append([]*SubmitTxTask{}, manager.txQueue...)
+	routes := make([]*routeTasks, 0, len(manager.routes))
+	for _, rt := range manager.routes {
+		routes = append(routes, rt)
 	}
+	manager.lock.Unlock()
 
-	for _, mtask := range manager.monitorQueue {
-		err := GlobalCoordinator.SendTaskToRelayer(mtask)
-		if err != nil {
-			panic(err)
-			return err
-		}
+	if err := manager.startTasks(monitors, schedules, txs); err != nil {
+		return err
 	}
-
-	for _, ttask := range manager.txQueue {
-		go func(t *SubmitTxTask) {
-			err := t.Start()
-			if err != nil {
-				panic(err)
-			}
-		}(ttask)
+	for _, rt := range routes {
+		rt.start(manager.ctx)
 	}
 
 	for {
@@ -87,6 +94,7 @@ func (manager *TaskManager) running() error {
 		case <-manager.ctx.Done():
 			log.Info("TaskManager::running() TaskManager received stop-signal and will be done")
 
+			manager.lock.Lock()
 			for _, ttask := range manager.txQueue {
 				ttask.Stop()
 			}
@@ -100,6 +108,7 @@ func (manager *TaskManager) running() error {
 					mtask.Stop()
 				}
 			}
+			manager.lock.Unlock()
 
 			manager.wg.Wait()
 			manager.SetStatus(TaskManagerStopped)
@@ -109,17 +118,57 @@ func (manager *TaskManager) running() error {
 
 }
 
+// startTasks starts the tasks, it must not be called with manager.lock held as the relayers may be busy taking tasks
+func (manager *TaskManager) startTasks(monitors []IMonitorTask, schedules []*ScheduleTask, txs []*SubmitTxTask) error {
+	for _, stask := range schedules {
+		if stask.Status() != ScheduleTaskNoStart {
+			return fmt.Errorf("TaskManager::startTasks() schedule-task %s already started", stask.name)
+		}
+	}
+	for _, ttask := range txs {
+		if ttask.Status() != SubmitTxTaskNoStart {
+			return fmt.Errorf("TaskManager::startTasks() submit-task %s already started", ttask.Name())
+		}
+	}
+
+	for _, stask := range schedules {
+		go func(t *ScheduleTask) {
+			if err := t.Start(); err != nil {
+				log.Error("TaskManager::startTasks() failed to start schedule-task", "schedule-task", t.name, "err", err.Error())
+			}
+		}(stask)
+	}
+
+	for _, mtask := range monitors {
+		err := manager.registry.SendTaskToRelayer(mtask)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, ttask := range txs {
+		go func(t *SubmitTxTask) {
+			if err := t.Start(); err != nil {
+				log.Error("TaskManager::startTasks() failed to start submit-task", "submit-task", t.Name(), "targetChain", t.TargetChainId(), "err", err.Error())
+			}
+		}(ttask)
+	}
+	return nil
+}
+
 func (manager *TaskManager) Stop() error {
 	if manager.Status() == TaskManagerDoing {
 		manager.cancelFunc()
 		manager.SetStatus(TaskManagerStopping)
+		return nil
 	}
 
 	return errors.New("TaskManager stop with invalid status")
-	return nil
 }
 
 func (manager *TaskManager) StopTaskBySpecificChainId(chainId uint64) {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
 	for _, task := range manager.monitorQueue {
 		if task.TargetChainId() == chainId && task.Status() == MonitorTaskMonitoring {
 			task.Stop()
@@ -128,6 +177,13 @@ func (manager *TaskManager) StopTaskBySpecificChainId(chainId uint64) {
 	}
 }
 
+// SetContracts replaces the contracts config that the tasks of routes added afterwards are built with
+func (manager *TaskManager) SetContracts(contracts ContractsConfig) {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+	manager.contracts = contracts
+}
+
 func (manager *TaskManager) Status() uint32 {
 	return atomic.LoadUint32(&manager.status)
 }
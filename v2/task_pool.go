@@ -34,6 +34,36 @@ func (pool *TaskPool) AddScheduleTask(t *ScheduleTask) *TaskPool {
 	return pool
 }
 
+func (pool *TaskPool) RemoveMonitorTask(t IMonitorTask) *TaskPool {
+	for i, task := range pool.monitorQueue {
+		if task == t {
+			pool.monitorQueue = append(pool.monitorQueue[:i], pool.monitorQueue[i+1:]...)
+			break
+		}
+	}
+	return pool
+}
+
+func (pool *TaskPool) RemoveSubmitTxTask(t *SubmitTxTask) *TaskPool {
+	for i, task := range pool.txQueue {
+		if task == t {
+			pool.txQueue = append(pool.txQueue[:i], pool.txQueue[i+1:]...)
+			break
+		}
+	}
+	return pool
+}
+
+func (pool *TaskPool) RemoveScheduleTask(t *ScheduleTask) *TaskPool {
+	for i, task := range pool.scheduleQueue {
+		if task == t {
+			pool.scheduleQueue = append(pool.scheduleQueue[:i], pool.scheduleQueue[i+1:]...)
+			break
+		}
+	}
+	return pool
+}
+
 // how to process the outcome of transaction execution
 // case1 : succeed
 // case2 : failed